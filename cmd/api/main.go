@@ -18,9 +18,21 @@ func main() {
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "migrate":
-			commands.Migrate()
+			commands.Migrate(os.Args[2:])
 		case "makemigrations":
-			commands.MakeMigrations()
+			commands.MakeMigrations(os.Args[2:])
+		case "rollback":
+			commands.Rollback(os.Args[2:])
+		case "showmigrations":
+			commands.ShowMigrations(os.Args[2:])
+		case "sqlmigrate":
+			commands.SqlMigrate(os.Args[2:])
+		case "check":
+			commands.Check(os.Args[2:])
+		case "inspectdb":
+			commands.InspectDB(os.Args[2:])
+		case "squashmigrations":
+			commands.SquashMigrations(os.Args[2:])
 		default:
 			log.Panicf("Comando inválido: %s", os.Args[1])
 		}
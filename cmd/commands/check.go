@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"test/internal/database"
+	"test/internal/migrations"
+)
+
+// Check verifica se as migrações já aplicadas no banco continuam idênticas aos
+// arquivos em migrations/ e se não há números de migração em conflito. Com
+// --schema, também compara as tabelas do banco com os models e com as
+// migrações aplicadas. Encerra com código 1 quando alguma divergência é
+// encontrada, para uso em CI e antes de deploys.
+func Check(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	schema := flags.Bool("schema", false, "compara o schema do banco com os models e as migrações aplicadas")
+	flags.Parse(args)
+
+	migrationsDir := "migrations"
+
+	allFiles, err := migrations.ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	// O check não altera o banco: sem gaver_migrations, nada foi aplicado
+	applied, err := migrations.ListAppliedMigrationsIfExists(database.DB)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	mismatches, err := migrations.VerifyChecksums(allFiles, applied)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	failed := false
+	if len(mismatches) > 0 {
+		reportChecksumMismatches(mismatches)
+		failed = true
+	} else {
+		log.Printf("%d migração(ões) aplicada(s) conferida(s). Nenhuma alteração encontrada.", len(applied))
+	}
+
+	if conflicts := migrations.FindMigrationConflicts(allFiles); len(conflicts) > 0 {
+		reportMigrationConflicts(conflicts)
+		failed = true
+	}
+
+	if *schema && checkSchema(migrationsDir, allFiles, applied) {
+		failed = true
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// checkSchema compara o banco conectado com os models e com o schema das
+// migrações aplicadas. Retorna true quando há divergências.
+func checkSchema(migrationsDir string, allFiles []migrations.MigrationFile, applied []migrations.AppliedMigration) bool {
+	module, err := migrations.ReadGaverModule()
+	if err != nil {
+		log.Panic("Erro ao ler gaverModule.json: ", err)
+	}
+
+	dialect, err := migrations.DialectFor(module.ProjectDatabaseType)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	current, err := dialect.InspectSchema(database.DB)
+	if err != nil {
+		log.Panic("Erro ao ler o schema do banco: ", err)
+	}
+
+	models, _, err := migrations.ScanModelsFromModules()
+	if err != nil {
+		log.Panic("Erro ao escanear models: ", err)
+	}
+
+	appliedModels, err := migrations.LoadAppliedSchema(dialect, migrationsDir, applied)
+	if err != nil {
+		log.Panic("Erro ao carregar o schema das migrações aplicadas: ", err)
+	}
+
+	if pending := migrations.PendingMigrations(allFiles, applied); len(pending) > 0 {
+		log.Printf("Aviso: %d migração(ões) pendente(s); as diferenças em relação aos models podem vir delas.", len(pending))
+	}
+
+	modelDrifts := migrations.CompareSchema(dialect, models, current)
+	reportSchemaDrifts("os models", modelDrifts)
+
+	migrationDrifts := migrations.CompareSchema(dialect, appliedModels, current)
+	reportSchemaDrifts("as migrações aplicadas", migrationDrifts)
+
+	return len(modelDrifts) > 0 || len(migrationDrifts) > 0
+}
+
+func reportChecksumMismatches(mismatches []migrations.ChecksumMismatch) {
+	log.Printf("%d migração(ões) aplicada(s) não correspondem aos arquivos:", len(mismatches))
+	for _, mismatch := range mismatches {
+		log.Printf("  %s", mismatch)
+	}
+}
+
+func reportSchemaDrifts(source string, drifts []migrations.SchemaDrift) {
+	if len(drifts) == 0 {
+		log.Printf("Nenhuma diferença entre o banco e %s.", source)
+		return
+	}
+
+	log.Printf("%d diferença(s) entre o banco e %s:", len(drifts), source)
+	for _, drift := range drifts {
+		log.Printf("  %s", drift)
+	}
+}
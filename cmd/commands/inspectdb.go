@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"test/internal/database"
+	"test/internal/migrations"
+)
+
+// InspectDB gera os models de modules/<módulo>/models a partir das tabelas do
+// banco conectado. Tabelas que já têm model em algum módulo são ignoradas.
+// Quando ainda não há migrações, também cria a migração inicial com o schema
+// atual e a registra como aplicada, já que as tabelas existem no banco.
+func InspectDB(args []string) {
+	flags := flag.NewFlagSet("inspectdb", flag.ExitOnError)
+	force := flags.Bool("force", false, "sobrescreve arquivos de model existentes")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Panic("Uso: inspectdb <módulo> [--force]")
+	}
+
+	moduleName := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+
+	module, err := migrations.ReadGaverModule()
+	if err != nil {
+		log.Panic("Erro ao ler gaverModule.json: ", err)
+	}
+
+	dialect, err := migrations.DialectFor(module.ProjectDatabaseType)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	tables, err := dialect.InspectSchema(database.DB)
+	if err != nil {
+		log.Panic("Erro ao ler o schema do banco: ", err)
+	}
+
+	existing, _, err := migrations.ScanModelsFromModules()
+	if err != nil {
+		log.Panic("Erro ao escanear models: ", err)
+	}
+
+	mapped := make(map[string]bool)
+	for _, model := range existing {
+		mapped[strings.ToLower(model.TableName)] = true
+	}
+
+	var unmappedTables []migrations.ModelInfo
+	for _, table := range tables {
+		if mapped[strings.ToLower(table.TableName)] {
+			log.Printf("Tabela %s já tem model; ignorada.", table.TableName)
+			continue
+		}
+		unmappedTables = append(unmappedTables, table)
+	}
+
+	if len(unmappedTables) == 0 {
+		log.Println("Nenhuma tabela sem model encontrada no banco.")
+		return
+	}
+
+	generated, warnings, err := migrations.GenerateModels(dialect, unmappedTables)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	modelsDir := filepath.Join("modules", moduleName, "models")
+	if !*force {
+		for _, model := range generated {
+			if _, err := os.Stat(filepath.Join(modelsDir, model.FileName)); err == nil {
+				log.Panicf("O arquivo %s já existe. Use --force para sobrescrever.", filepath.Join(modelsDir, model.FileName))
+			}
+		}
+	}
+
+	if err := os.MkdirAll(modelsDir, 0755); err != nil {
+		log.Panic("Erro ao criar diretório de models: ", err)
+	}
+
+	for _, model := range generated {
+		if err := os.WriteFile(filepath.Join(modelsDir, model.FileName), model.Source, 0644); err != nil {
+			log.Panic("Erro ao escrever model: ", err)
+		}
+		log.Printf("Model %s criado em %s (tabela %s)", model.StructName, filepath.Join(modelsDir, model.FileName), model.TableName)
+	}
+
+	for _, warning := range warnings {
+		log.Println("Aviso: " + warning)
+	}
+
+	if !containsString(module.ProjectModules, moduleName) {
+		module.ProjectModules = append(module.ProjectModules, moduleName)
+		if err := migrations.WriteGaverModule(module); err != nil {
+			log.Panic("Erro ao atualizar gaverModule.json: ", err)
+		}
+	}
+
+	writeBaselineMigration(dialect, module, tables, unmappedTables)
+}
+
+// writeBaselineMigration cria a migração inicial com todas as tabelas do banco
+// e a registra como aplicada sem executá-la.
+func writeBaselineMigration(dialect migrations.Dialect, module *migrations.GaverModule, tables, generatedTables []migrations.ModelInfo) {
+	migrationsDir := "migrations"
+
+	allFiles, err := migrations.ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	if len(allFiles) > 0 {
+		log.Println("Aviso: já existem migrações; a migração inicial não foi criada. Use makemigrations para incluir as tabelas.")
+		return
+	}
+
+	models, _, err := migrations.ScanModelsFromModules()
+	if err != nil {
+		log.Panic("Erro ao escanear models: ", err)
+	}
+
+	// Confere se os models gerados reproduzem as tabelas do banco
+	generated := make(map[string]bool)
+	for _, table := range generatedTables {
+		generated[strings.ToLower(table.TableName)] = true
+	}
+
+	var generatedModels []migrations.ModelInfo
+	for _, model := range models {
+		if generated[strings.ToLower(model.TableName)] {
+			generatedModels = append(generatedModels, model)
+		}
+	}
+	if diff := migrations.DiffModels(generatedTables, generatedModels, dialect); !diff.IsEmpty() {
+		log.Println("Aviso: os models gerados não reproduzem exatamente o banco; revise-os e rode makemigrations para ver as diferenças.")
+	}
+
+	number := module.MigrationTag + 1
+	migrationFile := migrations.MigrationFile{
+		Path:     filepath.Join(migrationsDir, fmt.Sprintf("%04d_inspectdb.sql", number)),
+		Number:   number,
+		Name:     "inspectdb",
+		FullName: fmt.Sprintf("%04d_inspectdb.sql", number),
+	}
+
+	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+		log.Panic("Erro ao criar diretório de migrações: ", err)
+	}
+
+	upSQL := migrations.GenerateSQL(dialect, migrations.SchemaDiff{CreatedTables: tables})
+	downSQL := migrations.GenerateSQL(dialect, migrations.SchemaDiff{DroppedTables: tables})
+	if err := os.WriteFile(migrationFile.Path, []byte(migrations.FormatMigration(upSQL, downSQL)), 0644); err != nil {
+		log.Panic("Erro ao escrever arquivo de migração: ", err)
+	}
+
+	snapshot := migrations.SchemaSnapshot{
+		MigrationNumber: number,
+		MigrationName:   strings.TrimSuffix(migrationFile.FullName, ".sql"),
+		Models:          tables,
+	}
+	if err := migrations.WriteSnapshot(migrationFile.Path, snapshot); err != nil {
+		log.Panic("Erro ao escrever snapshot do schema: ", err)
+	}
+
+	checksum, err := migrations.FileChecksum(migrationFile.Path)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if err := migrations.EnsureHistoryTable(database.DB); err != nil {
+		log.Panic(err)
+	}
+
+	if err := migrations.RecordMigration(database.DB, migrationFile, checksum, 0); err != nil {
+		log.Panic(err)
+	}
+
+	module.MigrationTag = number
+	if err := migrations.WriteGaverModule(module); err != nil {
+		log.Panic("Erro ao atualizar gaverModule.json: ", err)
+	}
+
+	log.Printf("Migração inicial criada e registrada como aplicada: %s", migrationFile.FullName)
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
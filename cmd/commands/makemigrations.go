@@ -1,71 +1,219 @@
 package commands
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"test/internal/database"
 	"test/internal/migrations"
 )
 
-func MakeMigrations() {
+// MakeMigrations gera a migração com as diferenças entre os models e o schema
+// das migrações existentes. O nome do arquivo descreve as alterações, ou é o
+// informado em --name. Arquivos com o mesmo número, vindos de branches
+// diferentes, impedem a geração até serem renumerados com --merge, que apenas
+// renumera os arquivos. Com --check nenhum arquivo é criado: o comando encerra
+// com código 1 se os models tiverem alterações sem migração, para uso em CI.
+func MakeMigrations(args []string) {
+	flags := flag.NewFlagSet("makemigrations", flag.ExitOnError)
+	name := flags.String("name", "", "nome da migração, em vez do gerado a partir das alterações")
+	merge := flags.Bool("merge", false, "renumera migrações com números em conflito")
+	check := flags.Bool("check", false, "apenas verifica se há alterações sem migração, sem criar arquivos")
+	flags.Parse(args)
+
+	if *merge && (*check || *name != "") {
+		log.Panic("--merge não pode ser usado com --check ou --name.")
+	}
+
+	if *name != "" && migrations.SanitizeMigrationName(*name) != *name {
+		log.Panicf("Nome de migração inválido: %s. Use apenas letras minúsculas, números e _.", *name)
+	}
+
 	module, err := migrations.ReadGaverModule()
 	if err != nil {
 		log.Panic("Erro ao ler gaverModule.json: ", err)
 	}
 
-	if module.ProjectDatabaseType != "sqlite" {
-		log.Panicf("Tipo de banco de dados incorreto. Esperado: sqlite, obtido: %s", module.ProjectDatabaseType)
+	dialect, err := migrations.DialectFor(module.ProjectDatabaseType)
+	if err != nil {
+		log.Panic(err)
 	}
 
-	models, err := migrations.ScanModelsFromModules()
+	migrationsDir := "migrations"
+
+	if *merge {
+		mergeMigrations(module, dialect, migrationsDir)
+		return
+	}
+
+	models, unmapped, err := migrations.ScanModelsFromModules()
 	if err != nil {
 		log.Panic("Erro ao escanear models: ", err)
 	}
 
-	if len(models) == 0 {
+	if len(unmapped) > 0 {
+		log.Println("Aviso: o scanner não conseguiu mapear os campos abaixo. Campos sem motivo indicado serão criados como texto; use a tag gorm:\"type:...\" para definir o tipo da coluna:")
+		for _, field := range unmapped {
+			log.Println("  - " + field.String())
+		}
+	}
+
+	allFiles, err := migrations.ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	if conflicts := migrations.FindMigrationConflicts(allFiles); len(conflicts) > 0 {
+		reportMigrationConflicts(conflicts)
+		if *check {
+			log.Println("Migrações com números em conflito. Rode makemigrations --merge para renumerá-las.")
+			os.Exit(1)
+		}
+		log.Panic("Migrações com números em conflito. Rode makemigrations --merge para renumerá-las.")
+	}
+
+	previousModels, err := migrations.LoadSchemaFromMigrations(dialect, migrationsDir)
+	if err != nil {
+		log.Panic("Erro ao carregar o schema das migrações anteriores: ", err)
+	}
+
+	if len(models) == 0 && len(previousModels) == 0 {
 		log.Println("Nenhum model encontrado em modules/*/models. Nada para migrar.")
 		return
 	}
 
+	diff := migrations.DiffModels(previousModels, models, dialect)
+	upSQL := migrations.GenerateSQL(dialect, diff)
+
+	// Diferenças que o dialeto não expressa em SQL também não geram arquivo
+	if diff.IsEmpty() || len(migrations.SplitSQLStatements(dialect, upSQL)) == 0 {
+		log.Println("Nenhuma alteração detectada nos models. Nada para migrar.")
+		return
+	}
+
+	if *check {
+		log.Println("Os models têm alterações sem migração:")
+		for _, line := range migrations.DescribeDiff(diff) {
+			log.Printf("  %s", line)
+		}
+		log.Println("Rode makemigrations e inclua a migração gerada.")
+		os.Exit(1)
+	}
+
+	// O migrationTag pode estar atrás dos arquivos, por exemplo após um merge
+	// ou com migrações em Go registradas, que não o alteram
 	nextMigrationTag := module.MigrationTag + 1
-	migrationFileName := fmt.Sprintf("%04d_%s.sql", nextMigrationTag, generateMigrationName(models))
+	for _, file := range allFiles {
+		if file.Number >= nextMigrationTag {
+			nextMigrationTag = file.Number + 1
+		}
+	}
+
+	migrationName := *name
+	if migrationName == "" {
+		migrationName = migrations.MigrationName(diff)
+	}
+	migrationFileName := fmt.Sprintf("%04d_%s.sql", nextMigrationTag, migrationName)
 
-	migrationsDir := "migrations"
 	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
 		log.Panic("Erro ao criar diretório de migrações: ", err)
 	}
 
 	migrationPath := filepath.Join(migrationsDir, migrationFileName)
 
-	sql := migrations.GenerateSQLForSQLite(models)
+	downSQL := migrations.GenerateSQL(dialect, migrations.DiffModels(models, previousModels, dialect))
+	sql := migrations.FormatMigration(upSQL, downSQL)
 
 	if err := os.WriteFile(migrationPath, []byte(sql), 0644); err != nil {
 		log.Panic("Erro ao escrever arquivo de migração: ", err)
 	}
 
+	snapshot := migrations.SchemaSnapshot{
+		MigrationNumber: nextMigrationTag,
+		MigrationName:   strings.TrimSuffix(migrationFileName, ".sql"),
+		Models:          models,
+	}
+	if err := migrations.WriteSnapshot(migrationPath, snapshot); err != nil {
+		log.Panic("Erro ao escrever snapshot do schema: ", err)
+	}
+
 	module.MigrationTag = nextMigrationTag
 	if err := migrations.WriteGaverModule(module); err != nil {
 		log.Panic("Erro ao atualizar gaverModule.json: ", err)
 	}
 
 	log.Printf("Migração criada: %s", migrationFileName)
+	log.Printf("Snapshot do schema: %s", filepath.Base(migrations.SnapshotPath(migrationPath)))
 	log.Printf("MigrationTag atualizado para: %d", nextMigrationTag)
 }
 
-func generateMigrationName(models []migrations.ModelInfo) string {
-	if len(models) == 0 {
-		return "empty"
+// mergeMigrations renumera os arquivos em conflito e atualiza o registro dos
+// que já foram aplicados no banco conectado. Nenhuma migração é gerada: as
+// diferenças que restarem entre os models e o schema combinado ficam para o
+// próximo makemigrations, que pode ser revisado separadamente.
+func mergeMigrations(module *migrations.GaverModule, dialect migrations.Dialect, migrationsDir string) {
+	files, err := migrations.ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	conflicts := migrations.FindMigrationConflicts(files)
+	if len(conflicts) == 0 {
+		log.Println("Nenhum conflito de numeração encontrado.")
+		return
+	}
+
+	reportMigrationConflicts(conflicts)
+
+	if err := migrations.EnsureHistoryTable(database.DB); err != nil {
+		log.Panic(err)
+	}
+
+	applied, err := migrations.ListAppliedMigrations(database.DB)
+	if err != nil {
+		log.Panic(err)
 	}
 
-	if len(models) == 1 {
-		return strings.ToLower(models[0].TableName)
+	renumbered, err := migrations.RenumberConflicts(dialect, migrationsDir, files, applied)
+	for _, migration := range renumbered {
+		log.Printf("Migração renumerada: %s -> %s", migration.Previous.FullName, migration.Current.FullName)
+
+		if migration.Applied {
+			if err := migrations.RenumberMigrationRecord(database.DB, migration); err != nil {
+				log.Panic(err)
+			}
+			log.Printf("Registro da migração atualizado no banco conectado: %04d -> %04d", migration.Previous.Number, migration.Current.Number)
+		}
+	}
+	if err != nil {
+		log.Panic(err)
 	}
 
-	timestamp := time.Now().Format("20060102_150405")
-	return fmt.Sprintf("multiple_%s", timestamp)
+	for _, migration := range renumbered {
+		if !migration.Applied {
+			log.Printf("Aviso: bancos que já aplicaram %s devem atualizar o registro: UPDATE %s SET number = %d WHERE number = %d AND name = '%s';",
+				migration.Previous.FullName, migrations.HistoryTable, migration.Current.Number, migration.Previous.Number, migration.Previous.Name)
+		}
+	}
+
+	last := renumbered[len(renumbered)-1].Current.Number
+	if last > module.MigrationTag {
+		module.MigrationTag = last
+		if err := migrations.WriteGaverModule(module); err != nil {
+			log.Panic("Erro ao atualizar gaverModule.json: ", err)
+		}
+	}
+
+	log.Println("Conflitos resolvidos. Rode makemigrations para gerar uma migração com as diferenças que restarem.")
 }
 
+func reportMigrationConflicts(conflicts []migrations.MigrationConflict) {
+	log.Printf("%d número(s) de migração em conflito:", len(conflicts))
+	for _, conflict := range conflicts {
+		log.Printf("  %s", conflict)
+	}
+}
@@ -1,22 +1,34 @@
 package commands
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
-	"strings"
+	"time"
 
 	"test/internal/database"
 	"test/internal/migrations"
 )
 
-func Migrate() {
+// Migrate executa as migrações pendentes. Com --dry-run apenas imprime os
+// comandos de cada arquivo pendente, sem executá-los. A execução obtém antes o
+// bloqueio de migrações, para que réplicas iniciadas juntas não apliquem os
+// mesmos arquivos; --lock-timeout define quanto tempo esperar por ele.
+func Migrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "mostra os comandos sem executá-los")
+	lockTimeout := flags.Duration("lock-timeout", migrations.DefaultLockTimeout, "tempo máximo de espera pelo bloqueio de migrações")
+	flags.Parse(args)
+
 	module, err := migrations.ReadGaverModule()
 	if err != nil {
 		log.Panic("Erro ao ler gaverModule.json: ", err)
 	}
 
-	if module.ProjectDatabaseType != "sqlite" {
-		log.Panicf("Tipo de banco de dados incorreto. Esperado: sqlite, obtido: %s", module.ProjectDatabaseType)
+	dialect, err := migrations.DialectFor(module.ProjectDatabaseType)
+	if err != nil {
+		log.Panic(err)
 	}
 
 	migrationsDir := "migrations"
@@ -25,11 +37,47 @@ func Migrate() {
 		return
 	}
 
-	migrationFiles, err := migrations.ListMigrationFiles(migrationsDir, module.MigrationTag)
+	// O dry-run não altera o banco: não cria gaver_migrations nem obtém o
+	// bloqueio, que em alguns dialetos é uma tabela
+	if !*dryRun {
+		if err := migrations.EnsureHistoryTable(database.DB); err != nil {
+			log.Panic(err)
+		}
+
+		defer lockMigrations(dialect, *lockTimeout)()
+	}
+
+	allFiles, err := migrations.ListMigrationFiles(migrationsDir, 0)
 	if err != nil {
 		log.Panic("Erro ao listar arquivos de migração: ", err)
 	}
 
+	if conflicts := migrations.FindMigrationConflicts(allFiles); len(conflicts) > 0 {
+		reportMigrationConflicts(conflicts)
+		log.Panic("Migrações com números em conflito. Rode makemigrations --merge antes de migrar.")
+	}
+
+	applied, err := migrations.ListAppliedMigrationsIfExists(database.DB)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if len(applied) == 0 && module.MigrationTag > 0 {
+		applied = legacyAppliedMigrations(dialect, module, allFiles, *dryRun)
+	}
+
+	mismatches, err := migrations.VerifyChecksums(allFiles, applied)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if len(mismatches) > 0 {
+		reportChecksumMismatches(mismatches)
+		log.Panic("Migrações já aplicadas foram alteradas. Nenhuma migração foi executada.")
+	}
+
+	migrationFiles := migrations.PendingMigrations(allFiles, applied)
+
 	if len(migrationFiles) == 0 {
 		log.Println("Nenhuma migração pendente.")
 		return
@@ -37,34 +85,83 @@ func Migrate() {
 
 	log.Printf("Encontradas %d migração(ões) pendente(s).", len(migrationFiles))
 
-	for _, migrationFile := range migrationFiles {
-		log.Printf("Executando migração: %s", migrationFile.FullName)
-
-		sql, err := migrations.ReadMigrationFile(migrationFile.Path)
-		if err != nil {
-			log.Panicf("Erro ao ler arquivo de migração %s: %v", migrationFile.FullName, err)
-		}
-
-		statements := migrations.SplitSQLStatements(sql)
-		
-		for _, statement := range statements {
-			statement = strings.TrimSpace(statement)
-			if statement == "" || statement == ";" {
+	if *dryRun {
+		for _, migrationFile := range migrationFiles {
+			if migrationFile.Go != nil {
+				fmt.Printf("-- %s\n-- (migração em Go: os comandos só são conhecidos na execução)\n\n", migrationFile.FullName)
 				continue
 			}
 
-			if err := database.DB.Exec(statement).Error; err != nil {
-				log.Panicf("Erro ao executar migração %s: %v\nSQL: %s", migrationFile.FullName, err, statement)
+			up, _, err := migrations.ReadMigrationStatements(dialect, migrationFile.Path)
+			if err != nil {
+				log.Panicf("Erro ao ler arquivo de migração %s: %v", migrationFile.FullName, err)
 			}
+
+			fmt.Printf("-- %s\n", migrationFile.FullName)
+			printStatements(up)
 		}
 
-		if err := migrations.UpdateMigrationTag(migrationFile.Number); err != nil {
-			log.Panicf("Erro ao atualizar migrationTag após migração %s: %v", migrationFile.FullName, err)
+		log.Println("Dry-run: nenhuma migração foi executada.")
+		return
+	}
+
+	for _, migrationFile := range migrationFiles {
+		log.Printf("Executando migração: %s", migrationFile.FullName)
+
+		if err := migrations.ApplyMigration(database.DB, dialect, migrationFile); err != nil {
+			log.Panic(err)
 		}
 
-		log.Printf("Migração %s executada com sucesso. MigrationTag atualizado para: %d", migrationFile.FullName, migrationFile.Number)
+		log.Printf("Migração %s executada com sucesso.", migrationFile.FullName)
 	}
 
 	log.Println("Todas as migrações foram executadas com sucesso!")
 }
 
+// lockMigrations obtém o bloqueio de migrações e retorna a função que o libera,
+// a ser chamada com defer para que o bloqueio seja liberado mesmo em caso de
+// erro.
+func lockMigrations(dialect migrations.Dialect, timeout time.Duration) func() {
+	release, err := migrations.AcquireMigrationLock(database.DB, dialect, timeout)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return func() {
+		if err := release(); err != nil {
+			log.Println("Aviso: ", err)
+		}
+	}
+}
+
+// legacyAppliedMigrations trata bancos migrados antes de gaver_migrations,
+// quando o migrationTag guardava a última migração aplicada: as migrações até
+// ele são registradas como aplicadas. Um banco sem tabelas é um banco novo de
+// um projeto que apenas gerou migrações, e nada é registrado.
+func legacyAppliedMigrations(dialect migrations.Dialect, module *migrations.GaverModule, allFiles []migrations.MigrationFile, dryRun bool) []migrations.AppliedMigration {
+	tables, err := dialect.InspectSchema(database.DB)
+	if err != nil {
+		log.Panic("Erro ao ler o schema do banco: ", err)
+	}
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	applied, err := migrations.LegacyAppliedMigrations(allFiles, module.MigrationTag)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if dryRun {
+		log.Printf("Histórico de migrações vazio: %d migração(ões) até o migrationTag %d seriam registrada(s) como aplicada(s).", len(applied), module.MigrationTag)
+		return applied
+	}
+
+	if err := migrations.RecordAppliedMigrations(database.DB, applied); err != nil {
+		log.Panic(err)
+	}
+
+	log.Printf("Histórico de migrações vazio: %d migração(ões) até o migrationTag %d registrada(s) como aplicada(s).", len(applied), module.MigrationTag)
+	return applied
+}
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"test/internal/database"
+	"test/internal/migrations"
+)
+
+// Rollback reverte migrações aplicadas executando suas seções down em ordem
+// inversa e removendo seus registros de gaver_migrations. Aceita a quantidade
+// de migrações a reverter (padrão 1) ou --to TAG para voltar até uma migração
+// específica. Assim como o migrate, executa com o bloqueio de migrações.
+func Rollback(args []string) {
+	flags := flag.NewFlagSet("rollback", flag.ExitOnError)
+	targetTag := flags.Int("to", -1, "número da migração até a qual reverter")
+	lockTimeout := flags.Duration("lock-timeout", migrations.DefaultLockTimeout, "tempo máximo de espera pelo bloqueio de migrações")
+	flags.Parse(args)
+
+	steps := 1
+	if flags.NArg() > 0 {
+		var err error
+		steps, err = strconv.Atoi(flags.Arg(0))
+		if err != nil || steps < 1 {
+			log.Panicf("Quantidade de migrações inválida: %s", flags.Arg(0))
+		}
+
+		// Permite as flags também depois da quantidade: rollback 2 --to 5
+		flags.Parse(flags.Args()[1:])
+		if flags.NArg() > 0 {
+			log.Panicf("Argumentos inesperados: %s", strings.Join(flags.Args(), " "))
+		}
+	}
+
+	module, err := migrations.ReadGaverModule()
+	if err != nil {
+		log.Panic("Erro ao ler gaverModule.json: ", err)
+	}
+
+	dialect, err := migrations.DialectFor(module.ProjectDatabaseType)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	migrationsDir := "migrations"
+	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
+		log.Println("Diretório de migrações não encontrado. Nada para reverter.")
+		return
+	}
+
+	if err := migrations.EnsureHistoryTable(database.DB); err != nil {
+		log.Panic(err)
+	}
+
+	defer lockMigrations(dialect, *lockTimeout)()
+
+	allFiles, err := migrations.ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	applied, err := migrations.ListAppliedMigrations(database.DB)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// Um squash corresponde aos registros de todas as migrações que substitui
+	filesByNumber := make(map[int]migrations.MigrationFile)
+	for _, migrationFile := range allFiles {
+		filesByNumber[migrationFile.Number] = migrationFile
+		for _, replaced := range migrationFile.Replaces {
+			filesByNumber[replaced.Number] = migrationFile
+		}
+	}
+
+	mismatches, err := migrations.VerifyChecksums(allFiles, applied)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if len(mismatches) > 0 {
+		reportChecksumMismatches(mismatches)
+		log.Panic("Migrações já aplicadas foram alteradas. Nenhuma migração foi revertida.")
+	}
+
+	var appliedFiles []migrations.MigrationFile
+	for _, migration := range applied {
+		migrationFile, ok := filesByNumber[migration.Number]
+		if !ok {
+			log.Panicf("A migração aplicada %04d_%s não foi encontrada em %s.", migration.Number, migration.Name, migrationsDir)
+		}
+
+		if len(appliedFiles) > 0 && appliedFiles[len(appliedFiles)-1].FullName == migrationFile.FullName {
+			continue
+		}
+		appliedFiles = append(appliedFiles, migrationFile)
+	}
+
+	if len(appliedFiles) == 0 {
+		log.Println("Nenhuma migração aplicada. Nada para reverter.")
+		return
+	}
+
+	var filesToRevert []migrations.MigrationFile
+	for i := len(appliedFiles) - 1; i >= 0; i-- {
+		if *targetTag >= 0 {
+			if appliedFiles[i].Number <= *targetTag {
+				break
+			}
+		} else if len(filesToRevert) == steps {
+			break
+		}
+
+		filesToRevert = append(filesToRevert, appliedFiles[i])
+	}
+
+	if len(filesToRevert) == 0 {
+		log.Println("Nenhuma migração para reverter.")
+		return
+	}
+
+	log.Printf("Revertendo %d migração(ões).", len(filesToRevert))
+
+	for _, migrationFile := range filesToRevert {
+		log.Printf("Revertendo migração: %s", migrationFile.FullName)
+
+		if err := migrations.RevertMigration(database.DB, dialect, migrationFile); err != nil {
+			log.Panic(err)
+		}
+
+		log.Printf("Migração %s revertida com sucesso.", migrationFile.FullName)
+	}
+
+	log.Println("Rollback concluído com sucesso!")
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"test/internal/database"
+	"test/internal/migrations"
+)
+
+// ShowMigrations lista todas as migrações encontradas em migrations/ com o
+// status de aplicação, data, estado do checksum e falhas na numeração. Com
+// --json imprime o relatório em JSON para scripts de deploy.
+func ShowMigrations(args []string) {
+	flags := flag.NewFlagSet("showmigrations", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "imprime o relatório em JSON")
+	flags.Parse(args)
+
+	migrationsDir := "migrations"
+
+	allFiles, err := migrations.ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	// Sem gaver_migrations todas as migrações aparecem como pendentes
+	applied, err := migrations.ListAppliedMigrationsIfExists(database.DB)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	report, err := migrations.BuildMigrationReport(allFiles, applied)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "    ")
+		if err := encoder.Encode(report); err != nil {
+			log.Panic("Erro ao codificar relatório: ", err)
+		}
+		return
+	}
+
+	if len(report.Migrations) == 0 {
+		fmt.Println("Nenhuma migração encontrada.")
+		return
+	}
+
+	for _, status := range report.Migrations {
+		mark := " "
+		if status.Applied {
+			mark = "X"
+		}
+
+		line := fmt.Sprintf("[%s] %04d_%s", mark, status.Number, status.Name)
+
+		if status.AppliedAt != nil {
+			line += fmt.Sprintf("  aplicada em %s", status.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+
+		switch status.Checksum {
+		case migrations.ChecksumChanged:
+			line += "  (arquivo alterado após aplicação)"
+		case migrations.ChecksumMissing:
+			line += "  (arquivo não encontrado)"
+		}
+
+		if len(status.Replaces) > 0 {
+			line += fmt.Sprintf("  (squash de %s a %s)", status.Replaces[0], status.Replaces[len(status.Replaces)-1])
+		}
+
+		if status.Duplicate {
+			line += "  (número duplicado)"
+		}
+
+		fmt.Println(line)
+	}
+
+	if len(report.Gaps) > 0 {
+		fmt.Printf("\nNúmeros ausentes na sequência: %v\n", report.Gaps)
+	}
+
+	if len(report.Duplicates) > 0 {
+		fmt.Printf("\nNúmeros duplicados: %v\n", report.Duplicates)
+	}
+}
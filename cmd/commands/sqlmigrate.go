@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"test/internal/migrations"
+)
+
+// SqlMigrate imprime os comandos de uma migração, na forma em que serão
+// executados pelo migrate (ou pelo rollback, com --down).
+func SqlMigrate(args []string) {
+	flags := flag.NewFlagSet("sqlmigrate", flag.ExitOnError)
+	down := flags.Bool("down", false, "mostra a seção down em vez da up")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Panic("Uso: sqlmigrate <número> [--down]")
+	}
+
+	number, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		log.Panicf("Número de migração inválido: %s", flags.Arg(0))
+	}
+
+	// Permite as flags também depois do número: sqlmigrate 3 --down
+	flags.Parse(flags.Args()[1:])
+
+	module, err := migrations.ReadGaverModule()
+	if err != nil {
+		log.Panic("Erro ao ler gaverModule.json: ", err)
+	}
+
+	dialect, err := migrations.DialectFor(module.ProjectDatabaseType)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	allFiles, err := migrations.ListMigrationFiles("migrations", 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	migrationFile, err := migrations.FindMigrationFile(allFiles, number)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if migrationFile.Go != nil {
+		log.Printf("A migração %s é escrita em Go; não há SQL para mostrar.", migrationFile.FullName)
+		return
+	}
+
+	up, downStatements, err := migrations.ReadMigrationStatements(dialect, migrationFile.Path)
+	if err != nil {
+		log.Panicf("Erro ao ler arquivo de migração %s: %v", migrationFile.FullName, err)
+	}
+
+	statements := up
+	if *down {
+		statements = downStatements
+	}
+
+	fmt.Printf("-- %s\n", migrationFile.FullName)
+	printStatements(statements)
+}
+
+func printStatements(statements []string) {
+	if len(statements) == 0 {
+		fmt.Println("-- (nenhum comando)")
+		return
+	}
+
+	for i, statement := range statements {
+		fmt.Printf("-- [%d]\n%s\n", i+1, statement)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"test/internal/migrations"
+)
+
+// SquashMigrations junta as migrações de FROM a TO em um único arquivo com o
+// schema resultante. Os arquivos originais são mantidos: bancos que já os
+// aplicaram seguem com eles e bancos novos executam apenas o squash.
+func SquashMigrations(args []string) {
+	flags := flag.NewFlagSet("squashmigrations", flag.ExitOnError)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		log.Panic("Uso: squashmigrations <de> <até>")
+	}
+
+	from, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		log.Panicf("Número de migração inválido: %s", flags.Arg(0))
+	}
+
+	to, err := strconv.Atoi(flags.Arg(1))
+	if err != nil {
+		log.Panicf("Número de migração inválido: %s", flags.Arg(1))
+	}
+
+	module, err := migrations.ReadGaverModule()
+	if err != nil {
+		log.Panic("Erro ao ler gaverModule.json: ", err)
+	}
+
+	dialect, err := migrations.DialectFor(module.ProjectDatabaseType)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	allFiles, err := migrations.ListMigrationFiles("migrations", 0)
+	if err != nil {
+		log.Panic("Erro ao listar arquivos de migração: ", err)
+	}
+
+	squashed, warnings, err := migrations.BuildSquashedMigration(dialect, allFiles, from, to)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if _, err := os.Stat(squashed.File.Path); err == nil {
+		log.Panicf("O arquivo %s já existe.", squashed.File.Path)
+	}
+
+	if err := os.WriteFile(squashed.File.Path, []byte(squashed.Content), 0644); err != nil {
+		log.Panic("Erro ao escrever arquivo de migração: ", err)
+	}
+
+	snapshot := migrations.SchemaSnapshot{
+		MigrationNumber: squashed.File.Number,
+		MigrationName:   strings.TrimSuffix(squashed.File.FullName, ".sql"),
+		Models:          squashed.Models,
+	}
+	if err := migrations.WriteSnapshot(squashed.File.Path, snapshot); err != nil {
+		log.Panic("Erro ao escrever snapshot do schema: ", err)
+	}
+
+	log.Printf("Migração criada: %s (substitui %d migrações)", squashed.File.FullName, len(squashed.File.Replaces))
+	log.Printf("Snapshot do schema: %s", filepath.Base(migrations.SnapshotPath(squashed.File.Path)))
+
+	for _, warning := range warnings {
+		log.Println("Aviso: " + warning)
+	}
+	if len(warnings) > 0 {
+		log.Println("Aviso: inclua manualmente no squash os comandos acima que ainda forem necessários.")
+	}
+
+	log.Println("Os arquivos originais podem ser removidos depois que todos os bancos tiverem aplicado essas migrações.")
+}
@@ -26,6 +26,7 @@ type envConfig struct {
 	DBPassword string
 	DBHost     string
 	DBPort     string
+	DBSSLMode  string
 
 	GinMode string
 	GinJWT  string
@@ -44,11 +45,16 @@ func init() {
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
+		DBSSLMode:  os.Getenv("DB_SSLMODE"),
 		GinMode:    os.Getenv("GIN_MODE"),
 		GinJWT:     os.Getenv("GIN_JWT"),
 		GinPort:    os.Getenv("GIN_PORT"),
 	}
 
+	if Env.DBSSLMode == "" {
+		Env.DBSSLMode = "disable"
+	}
+
 	if err := loadGaverSettings(&GaverSettings); err != nil {
 		log.Panic("Erro ao carregar as configurações do Gaver: ", err)
 	}
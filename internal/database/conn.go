@@ -1,9 +1,12 @@
 package database
 
 import (
-	"test/internal/config"
+	"fmt"
 	"log"
+	"test/internal/config"
 
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -13,8 +16,41 @@ var DB *gorm.DB
 func init() {
 	var err error
 
-	DB, err = gorm.Open(sqlite.Open("internal/database/" + config.Env.DBName + ".db"))
+	DB, err = gorm.Open(dialector())
 	if err != nil {
 		log.Panic("Erro ao conectar ao banco de dados: ", err)
 	}
 }
+
+// dialector escolhe o driver a partir de ProjectDatabaseType no gaverModule.json
+// e monta a conexão com as variáveis DB_* do .env.
+func dialector() gorm.Dialector {
+	switch config.GaverSettings.ProjectDatabaseType {
+	case "sqlite":
+		return sqlite.Open("internal/database/" + config.Env.DBName + ".db")
+	case "postgres":
+		dsn := fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			config.Env.DBHost,
+			config.Env.DBPort,
+			config.Env.DBUser,
+			config.Env.DBPassword,
+			config.Env.DBName,
+			config.Env.DBSSLMode,
+		)
+		return postgres.Open(dsn)
+	case "mysql":
+		dsn := fmt.Sprintf(
+			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.Env.DBUser,
+			config.Env.DBPassword,
+			config.Env.DBHost,
+			config.Env.DBPort,
+			config.Env.DBName,
+		)
+		return mysql.Open(dsn)
+	default:
+		log.Panicf("Tipo de banco de dados não suportado: %s", config.GaverSettings.ProjectDatabaseType)
+		return nil
+	}
+}
@@ -0,0 +1,393 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type SchemaDiff struct {
+	CreatedTables []ModelInfo
+	DroppedTables []ModelInfo
+	AlteredTables []TableDiff
+}
+
+type TableDiff struct {
+	Previous       ModelInfo
+	Current        ModelInfo
+	AddedColumns   []FieldInfo
+	DroppedColumns []FieldInfo
+	ChangedColumns []ColumnChange
+	CreatedIndexes []IndexInfo
+	DroppedIndexes []IndexInfo
+	CreatedChecks  []CheckInfo
+	DroppedChecks  []CheckInfo
+	// Chaves estrangeiras alteradas aparecem nas duas listas
+	CreatedForeignKeys []ForeignKeyInfo
+	DroppedForeignKeys []ForeignKeyInfo
+}
+
+type ColumnChange struct {
+	Previous FieldInfo
+	Current  FieldInfo
+}
+
+// DiffModels compara o schema anterior (derivado das migrações já geradas)
+// com os models escaneados e retorna apenas o que mudou. Os tipos das colunas
+// são comparados já resolvidos pelo dialeto do banco de destino.
+func DiffModels(previous, current []ModelInfo, dialect Dialect) SchemaDiff {
+	var diff SchemaDiff
+
+	previousTables := make(map[string]ModelInfo)
+	for _, model := range previous {
+		previousTables[strings.ToLower(model.TableName)] = model
+	}
+
+	currentTables := make(map[string]bool)
+	for _, model := range current {
+		currentTables[strings.ToLower(model.TableName)] = true
+
+		previousModel, ok := previousTables[strings.ToLower(model.TableName)]
+		if !ok {
+			diff.CreatedTables = append(diff.CreatedTables, model)
+			continue
+		}
+
+		tableDiff := diffTable(previousModel, model, dialect)
+		if !tableDiff.IsEmpty() {
+			diff.AlteredTables = append(diff.AlteredTables, tableDiff)
+		}
+	}
+
+	for _, model := range previous {
+		if !currentTables[strings.ToLower(model.TableName)] {
+			diff.DroppedTables = append(diff.DroppedTables, model)
+		}
+	}
+
+	return diff
+}
+
+func diffTable(previous, current ModelInfo, dialect Dialect) TableDiff {
+	tableDiff := TableDiff{
+		Previous: previous,
+		Current:  current,
+	}
+
+	for _, field := range current.Fields {
+		previousField, ok := findField(previous, columnName(field))
+		if !ok {
+			tableDiff.AddedColumns = append(tableDiff.AddedColumns, field)
+			continue
+		}
+
+		if !sameColumn(previousField, field, dialect) {
+			tableDiff.ChangedColumns = append(tableDiff.ChangedColumns, ColumnChange{
+				Previous: previousField,
+				Current:  field,
+			})
+		}
+	}
+
+	for _, field := range previous.Fields {
+		if _, ok := findField(current, columnName(field)); !ok {
+			tableDiff.DroppedColumns = append(tableDiff.DroppedColumns, field)
+		}
+	}
+
+	previousIndexes := indexesByName(modelIndexes(previous))
+	currentIndexes := indexesByName(modelIndexes(current))
+
+	for _, index := range modelIndexes(current) {
+		previousIndex, ok := previousIndexes[index.Name]
+		if !ok || !sameIndex(previousIndex, index, dialect) {
+			tableDiff.CreatedIndexes = append(tableDiff.CreatedIndexes, index)
+		}
+	}
+
+	for _, index := range modelIndexes(previous) {
+		currentIndex, ok := currentIndexes[index.Name]
+		if !ok || !sameIndex(index, currentIndex, dialect) {
+			tableDiff.DroppedIndexes = append(tableDiff.DroppedIndexes, index)
+		}
+	}
+
+	previousChecks := checksByName(previous.Checks)
+	currentChecks := checksByName(current.Checks)
+
+	for _, check := range current.Checks {
+		previousCheck, ok := previousChecks[check.Name]
+		if !ok || !sameCheck(previousCheck, check) {
+			tableDiff.CreatedChecks = append(tableDiff.CreatedChecks, check)
+		}
+	}
+
+	for _, check := range previous.Checks {
+		currentCheck, ok := currentChecks[check.Name]
+		if !ok || !sameCheck(check, currentCheck) {
+			tableDiff.DroppedChecks = append(tableDiff.DroppedChecks, check)
+		}
+	}
+
+	previousForeignKeys := foreignKeysByName(previous.ForeignKeys)
+	currentForeignKeys := foreignKeysByName(current.ForeignKeys)
+
+	for _, foreignKey := range current.ForeignKeys {
+		previousForeignKey, ok := previousForeignKeys[strings.ToLower(foreignKey.Name)]
+		if !ok || !sameForeignKey(previousForeignKey, foreignKey) {
+			tableDiff.CreatedForeignKeys = append(tableDiff.CreatedForeignKeys, foreignKey)
+		}
+	}
+
+	for _, foreignKey := range previous.ForeignKeys {
+		currentForeignKey, ok := currentForeignKeys[strings.ToLower(foreignKey.Name)]
+		if !ok || !sameForeignKey(foreignKey, currentForeignKey) {
+			tableDiff.DroppedForeignKeys = append(tableDiff.DroppedForeignKeys, foreignKey)
+		}
+	}
+
+	return tableDiff
+}
+
+func (d SchemaDiff) IsEmpty() bool {
+	return len(d.CreatedTables) == 0 && len(d.DroppedTables) == 0 && len(d.AlteredTables) == 0
+}
+
+func (t TableDiff) IsEmpty() bool {
+	return len(t.AddedColumns) == 0 &&
+		len(t.DroppedColumns) == 0 &&
+		len(t.ChangedColumns) == 0 &&
+		len(t.CreatedIndexes) == 0 &&
+		len(t.DroppedIndexes) == 0 &&
+		len(t.CreatedChecks) == 0 &&
+		len(t.DroppedChecks) == 0 &&
+		len(t.CreatedForeignKeys) == 0 &&
+		len(t.DroppedForeignKeys) == 0
+}
+
+func findField(model ModelInfo, column string) (FieldInfo, bool) {
+	for _, field := range model.Fields {
+		if strings.EqualFold(columnName(field), column) {
+			return field, true
+		}
+	}
+
+	return FieldInfo{}, false
+}
+
+func sameColumn(a, b FieldInfo, dialect Dialect) bool {
+	return strings.EqualFold(dialect.ColumnType(a), dialect.ColumnType(b)) &&
+		a.IsPrimaryKey == b.IsPrimaryKey &&
+		(a.IsNotNull || a.IsPrimaryKey) == (b.IsNotNull || b.IsPrimaryKey) &&
+		strings.TrimSpace(a.DefaultValue) == strings.TrimSpace(b.DefaultValue) &&
+		a.AutoIncrement == b.AutoIncrement &&
+		(!dialect.SupportsColumnComments() || a.Comment == b.Comment)
+}
+
+// sameIndex compara colunas, ordenação e unicidade. A condição WHERE só é
+// comparada nos bancos com índices parciais.
+func sameIndex(a, b IndexInfo, dialect Dialect) bool {
+	if a.Unique != b.Unique || !sameColumns(a.Columns, b.Columns) {
+		return false
+	}
+
+	for i := range a.Columns {
+		if indexSortOrDefault(a, i) != indexSortOrDefault(b, i) {
+			return false
+		}
+	}
+
+	return !dialect.SupportsPartialIndexes() ||
+		strings.Join(strings.Fields(a.Where), " ") == strings.Join(strings.Fields(b.Where), " ")
+}
+
+func indexSortOrDefault(index IndexInfo, i int) string {
+	if sort := indexSort(index, i); sort != "" {
+		return sort
+	}
+	return "ASC"
+}
+
+// sameForeignKey compara as ações sem diferenciar ausência de NO ACTION, que
+// é o comportamento padrão dos bancos.
+func sameForeignKey(a, b ForeignKeyInfo) bool {
+	return strings.EqualFold(a.RefTable, b.RefTable) &&
+		sameColumns(a.Columns, b.Columns) &&
+		sameColumns(a.RefColumns, b.RefColumns) &&
+		referentialAction(a.OnDelete) == referentialAction(b.OnDelete) &&
+		referentialAction(a.OnUpdate) == referentialAction(b.OnUpdate)
+}
+
+func referentialAction(action string) string {
+	action = strings.ToUpper(strings.Join(strings.Fields(action), " "))
+	if action == "" {
+		return "NO ACTION"
+	}
+	return action
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameCheck(a, b CheckInfo) bool {
+	return strings.Join(strings.Fields(a.Expression), " ") == strings.Join(strings.Fields(b.Expression), " ")
+}
+
+func checksByName(checks []CheckInfo) map[string]CheckInfo {
+	result := make(map[string]CheckInfo)
+	for _, check := range checks {
+		result[check.Name] = check
+	}
+	return result
+}
+
+func foreignKeysByName(foreignKeys []ForeignKeyInfo) map[string]ForeignKeyInfo {
+	result := make(map[string]ForeignKeyInfo)
+	for _, foreignKey := range foreignKeys {
+		result[strings.ToLower(foreignKey.Name)] = foreignKey
+	}
+	return result
+}
+
+func indexesByName(indexes []IndexInfo) map[string]IndexInfo {
+	result := make(map[string]IndexInfo)
+	for _, index := range indexes {
+		result[index.Name] = index
+	}
+	return result
+}
+
+// Tamanho máximo do nome gerado por MigrationName, sem o número
+const maxMigrationNameLength = 60
+
+var migrationNameInvalidChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// MigrationName descreve as alterações da diferença, por exemplo
+// "create_posts" ou "add_email_to_users_and_drop_tokens". O nome depende
+// apenas das alterações, para que o mesmo conjunto de mudanças gere sempre o
+// mesmo arquivo. Nomes longos demais ficam com a primeira alteração seguida
+// de "_and_more".
+func MigrationName(diff SchemaDiff) string {
+	var parts []string
+
+	for _, table := range diff.CreatedTables {
+		parts = append(parts, "create_"+table.TableName)
+	}
+
+	for _, table := range diff.AlteredTables {
+		parts = append(parts, tableDiffNames(table)...)
+	}
+
+	for _, table := range diff.DroppedTables {
+		parts = append(parts, "drop_"+table.TableName)
+	}
+
+	if len(parts) == 0 {
+		return "empty"
+	}
+
+	name := strings.Join(parts, "_and_")
+	if len(name) > maxMigrationNameLength {
+		name = parts[0] + "_and_more"
+	}
+
+	return SanitizeMigrationName(name)
+}
+
+func tableDiffNames(table TableDiff) []string {
+	tableName := table.Current.TableName
+
+	var parts []string
+	if len(table.AddedColumns) > 0 {
+		parts = append(parts, fmt.Sprintf("add_%s_to_%s", fieldNames(table.AddedColumns), tableName))
+	}
+
+	if len(table.DroppedColumns) > 0 {
+		parts = append(parts, fmt.Sprintf("remove_%s_from_%s", fieldNames(table.DroppedColumns), tableName))
+	}
+
+	if len(table.ChangedColumns) > 0 {
+		var fields []FieldInfo
+		for _, change := range table.ChangedColumns {
+			fields = append(fields, change.Current)
+		}
+		parts = append(parts, fmt.Sprintf("alter_%s_on_%s", fieldNames(fields), tableName))
+	}
+
+	// Índices e constraints só entram no nome quando são a única alteração
+	if len(parts) == 0 {
+		parts = append(parts, "alter_"+tableName)
+	}
+
+	return parts
+}
+
+func fieldNames(fields []FieldInfo) string {
+	return strings.Join(fieldColumns(fields), "_")
+}
+
+// SanitizeMigrationName deixa o nome apenas com letras minúsculas, números e
+// "_", como nos arquivos gerados.
+func SanitizeMigrationName(name string) string {
+	name = migrationNameInvalidChars.ReplaceAllString(strings.ToLower(name), "_")
+	return strings.Trim(name, "_")
+}
+
+// DescribeDiff lista as alterações da diferença em texto, uma por linha, para
+// exibição ao usuário.
+func DescribeDiff(diff SchemaDiff) []string {
+	var lines []string
+
+	for _, table := range diff.CreatedTables {
+		lines = append(lines, fmt.Sprintf("%s: tabela criada", table.TableName))
+	}
+
+	for _, table := range diff.AlteredTables {
+		tableName := table.Current.TableName
+
+		if len(table.AddedColumns) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: coluna(s) adicionada(s): %s", tableName, strings.Join(fieldColumns(table.AddedColumns), ", ")))
+		}
+
+		if len(table.DroppedColumns) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: coluna(s) removida(s): %s", tableName, strings.Join(fieldColumns(table.DroppedColumns), ", ")))
+		}
+
+		for _, change := range table.ChangedColumns {
+			lines = append(lines, fmt.Sprintf("%s: coluna alterada: %s", tableName, columnName(change.Current)))
+		}
+
+		if len(table.CreatedIndexes) > 0 || len(table.DroppedIndexes) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: índices alterados", tableName))
+		}
+
+		if len(table.CreatedChecks) > 0 || len(table.DroppedChecks) > 0 || len(table.CreatedForeignKeys) > 0 || len(table.DroppedForeignKeys) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: constraints alteradas", tableName))
+		}
+	}
+
+	for _, table := range diff.DroppedTables {
+		lines = append(lines, fmt.Sprintf("%s: tabela removida", table.TableName))
+	}
+
+	return lines
+}
+
+func fieldColumns(fields []FieldInfo) []string {
+	var columns []string
+	for _, field := range fields {
+		columns = append(columns, columnName(field))
+	}
+	return columns
+}
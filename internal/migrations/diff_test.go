@@ -0,0 +1,180 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// roundTripModels cobre os casos que o replay do SQL precisa reconstruir:
+// tipos, nulidade, defaults, índices com opções, checks, chave primária
+// composta e chaves estrangeiras.
+func roundTripModels() []ModelInfo {
+	orders := goldenOrders()
+	orders.Fields = append(orders.Fields,
+		FieldInfo{Name: "Status", Column: "status", Type: "string", IsNotNull: true, Size: 20, DefaultValue: "'aberto'"},
+		FieldInfo{Name: "Code", Column: "code", Type: "string", Size: 30, IsUnique: true},
+		FieldInfo{Name: "PlacedAt", Column: "placed_at", Type: "time.Time", IsIndex: true},
+	)
+	orders.Indexes = []IndexInfo{
+		{Name: "idx_orders_status_placed", Columns: []string{"status", "placed_at"}, Sorts: []string{"", "DESC"}},
+	}
+	orders.Checks = []CheckInfo{{Name: "chk_orders_total", Expression: "total >= 0"}}
+
+	orderItems := ModelInfo{
+		Name:      "OrderItem",
+		TableName: "order_items",
+		Fields: []FieldInfo{
+			{Name: "OrderID", Column: "order_id", Type: "uint", IsPrimaryKey: true},
+			{Name: "Line", Column: "line", Type: "int", IsPrimaryKey: true},
+			{Name: "Quantity", Column: "quantity", Type: "int", IsNotNull: true, DefaultValue: "1"},
+		},
+		ForeignKeys: []ForeignKeyInfo{
+			{Name: "fk_orders_items", Columns: []string{"order_id"}, RefTable: "orders", RefColumns: []string{"id"}, OnDelete: "CASCADE", OnUpdate: "CASCADE"},
+		},
+	}
+
+	return []ModelInfo{goldenUsers(), orders, orderItems}
+}
+
+// TestRoundTrip gera as migrações de cada caso, reconstrói o schema a partir
+// do SQL com loadSchema e confere que não sobra diferença para os models.
+func TestRoundTrip(t *testing.T) {
+	altered := roundTripModels()
+	altered[0].Fields[1].Size = 255
+	altered[0].Fields[1].IsNotNull = false
+	altered[0].Fields[2].IsNotNull = true
+	altered[1].Fields[2].DefaultValue = "5"
+	altered[1].Indexes = []IndexInfo{
+		{Name: "idx_orders_open", Columns: []string{"status"}, Where: "status = 'aberto'"},
+	}
+	altered[1].ForeignKeys[0].OnDelete = "SET NULL"
+	altered[1].Fields[1].IsNotNull = false
+
+	withoutRelations := roundTripModels()
+	withoutRelations[1].ForeignKeys = nil
+	withoutRelations[1].Checks = nil
+	withoutRelations[2].ForeignKeys = nil
+
+	tests := []struct {
+		name  string
+		steps [][]ModelInfo
+	}{
+		{"create", [][]ModelInfo{roundTripModels()}},
+		{"alter column, index e chave estrangeira", [][]ModelInfo{roundTripModels(), altered}},
+		{"adiciona chaves estrangeiras e checks", [][]ModelInfo{withoutRelations, roundTripModels()}},
+		{"remove chaves estrangeiras e checks", [][]ModelInfo{roundTripModels(), withoutRelations}},
+		{"drop table", [][]ModelInfo{roundTripModels(), {goldenUsers()}}},
+	}
+
+	for _, dialect := range []Dialect{SQLiteDialect{}, PostgresDialect{}, MySQLDialect{}} {
+		for _, tt := range tests {
+			t.Run(dialect.Name()+"/"+tt.name, func(t *testing.T) {
+				dir := t.TempDir()
+
+				var previous []ModelInfo
+				for i, current := range tt.steps {
+					writeModelMigration(t, dialect, dir, i+1, "step", previous, current)
+					previous = current
+				}
+
+				loaded, err := LoadSchemaFromMigrations(dialect, dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if diff := DiffModels(loaded, previous, dialect); !diff.IsEmpty() {
+					t.Errorf("diferença após reconstruir o schema:\n%s", strings.Join(DescribeDiff(diff), "\n"))
+				}
+
+				// A seção down de cada passo volta ao schema anterior
+				if len(tt.steps) > 1 {
+					before := tt.steps[len(tt.steps)-2]
+					reverted, err := loadSchemaReverting(dialect, dir)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if diff := DiffModels(reverted, before, dialect); !diff.IsEmpty() {
+						t.Errorf("diferença após reverter o último passo:\n%s", strings.Join(DescribeDiff(diff), "\n"))
+					}
+				}
+			})
+		}
+	}
+}
+
+// loadSchemaReverting reconstrói o schema aplicando todas as migrações e a
+// seção down da última.
+func loadSchemaReverting(dialect Dialect, dir string) ([]ModelInfo, error) {
+	files, err := ListMigrationFiles(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	state := newSchemaState()
+	for i, file := range files {
+		up, down, err := ReadMigrationStatements(dialect, file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		statements := up
+		if i == len(files)-1 {
+			statements = append(append([]string{}, up...), down...)
+		}
+
+		for _, statement := range statements {
+			if err := state.apply(statement); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return state.models(), nil
+}
+
+func TestMigrationName(t *testing.T) {
+	withPhone := goldenUsers()
+	withPhone.Fields = append(withPhone.Fields, FieldInfo{Name: "Phone", Column: "phone", Type: "string"})
+
+	withIndex := goldenUsers()
+	withIndex.Indexes = []IndexInfo{{Name: "idx_users_name", Columns: []string{"name"}}}
+
+	manyTables := func(prefix string, count int) []ModelInfo {
+		var models []ModelInfo
+		for i := 0; i < count; i++ {
+			model := goldenUsers()
+			model.TableName = fmt.Sprintf("%s_%d", prefix, i)
+			models = append(models, model)
+		}
+		return models
+	}
+
+	tests := []struct {
+		name     string
+		previous []ModelInfo
+		current  []ModelInfo
+		want     string
+	}{
+		{"tabela nova", nil, []ModelInfo{goldenUsers()}, "create_users"},
+		{"coluna nova", []ModelInfo{goldenUsers()}, []ModelInfo{withPhone}, "add_phone_to_users"},
+		{"coluna removida", []ModelInfo{withPhone}, []ModelInfo{goldenUsers()}, "remove_phone_from_users"},
+		{"apenas índice", []ModelInfo{goldenUsers()}, []ModelInfo{withIndex}, "alter_users"},
+		{"tabela removida", []ModelInfo{goldenUsers()}, nil, "drop_users"},
+		{"várias alterações", []ModelInfo{goldenUsers()}, []ModelInfo{withPhone, goldenOrders()}, "create_orders_and_add_phone_to_users"},
+		{"mais de 60 caracteres", nil, manyTables("customer_addresses", 3), "create_customer_addresses_0_and_more"},
+		{"sem alterações", []ModelInfo{goldenUsers()}, []ModelInfo{goldenUsers()}, "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MigrationName(DiffModels(tt.previous, tt.current, SQLiteDialect{}))
+			if got != tt.want {
+				t.Errorf("MigrationName = %s, esperado %s", got, tt.want)
+			}
+			if len(got) > maxMigrationNameLength {
+				t.Errorf("%s passa de %d caracteres", got, maxMigrationNameLength)
+			}
+		})
+	}
+}
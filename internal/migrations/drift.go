@@ -0,0 +1,320 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaDrift é uma diferença entre o schema esperado (models ou migrações
+// aplicadas) e o schema encontrado no banco conectado.
+type SchemaDrift struct {
+	Table   string
+	Column  string
+	Message string
+}
+
+func (d SchemaDrift) String() string {
+	if d.Column != "" {
+		return fmt.Sprintf("%s.%s: %s", d.Table, d.Column, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", d.Table, d.Message)
+}
+
+var (
+	sqlTypeSpacePattern = regexp.MustCompile(`\s*([(),])\s*`)
+
+	// Sem espaço depois da precisão, que sqlTypeSpacePattern remove
+	timestampZonePattern   = regexp.MustCompile(`^TIMESTAMP(\(\d+\))? ?WITH TIME ZONE$`)
+	timestampNoZonePattern = regexp.MustCompile(`^TIMESTAMP(\(\d+\))? ?WITHOUT TIME ZONE$`)
+)
+
+// sqlTypeSynonyms mapeia nomes alternativos de um mesmo tipo, como os
+// devolvidos pelo catálogo do PostgreSQL, para o nome usado pelos dialetos.
+var sqlTypeSynonyms = map[string]string{
+	"INT":               "INTEGER",
+	"INT4":              "INTEGER",
+	"SERIAL":            "INTEGER",
+	"SERIAL4":           "INTEGER",
+	"INT8":              "BIGINT",
+	"BIGSERIAL":         "BIGINT",
+	"SERIAL8":           "BIGINT",
+	"INT2":              "SMALLINT",
+	"SMALLSERIAL":       "SMALLINT",
+	"SERIAL2":           "SMALLINT",
+	"BOOL":              "BOOLEAN",
+	"FLOAT4":            "REAL",
+	"FLOAT8":            "DOUBLE PRECISION",
+	"DECIMAL":           "NUMERIC",
+	"CHARACTER VARYING": "VARCHAR",
+	"CHARACTER":         "CHAR",
+}
+
+// isInternalTable indica as tabelas criadas pela própria ferramenta, que não
+// fazem parte do schema dos models.
+func isInternalTable(name string) bool {
+	return strings.EqualFold(name, HistoryTable) || strings.EqualFold(name, LockTable)
+}
+
+// CompareSchema compara o schema esperado com o lido do banco por
+// Dialect.InspectSchema: tabelas e colunas ausentes ou sobrando, tipo,
+// nulidade, default, chave primária, índices e chaves estrangeiras ausentes ou
+// diferentes. Índices que existem apenas no banco não são reportados, pois
+// alguns bancos criam índices próprios (por exemplo para chaves estrangeiras
+// no MySQL).
+func CompareSchema(dialect Dialect, expected, actual []ModelInfo) []SchemaDrift {
+	var drifts []SchemaDrift
+
+	actualTables := make(map[string]ModelInfo)
+	for _, model := range actual {
+		actualTables[strings.ToLower(model.TableName)] = model
+	}
+
+	expectedTables := make(map[string]bool)
+	for _, model := range sortedModels(expected) {
+		expectedTables[strings.ToLower(model.TableName)] = true
+
+		current, ok := actualTables[strings.ToLower(model.TableName)]
+		if !ok {
+			drifts = append(drifts, SchemaDrift{Table: model.TableName, Message: "tabela não existe no banco"})
+			continue
+		}
+
+		drifts = append(drifts, compareTable(dialect, model, current)...)
+	}
+
+	for _, model := range sortedModels(actual) {
+		if !expectedTables[strings.ToLower(model.TableName)] && !isInternalTable(model.TableName) {
+			drifts = append(drifts, SchemaDrift{Table: model.TableName, Message: "tabela existe no banco, mas não é esperada"})
+		}
+	}
+
+	return drifts
+}
+
+func compareTable(dialect Dialect, expected, actual ModelInfo) []SchemaDrift {
+	var drifts []SchemaDrift
+	table := expected.TableName
+
+	for _, field := range expected.Fields {
+		column := columnName(field)
+
+		current, ok := findField(actual, column)
+		if !ok {
+			drifts = append(drifts, SchemaDrift{Table: table, Column: column, Message: "coluna não existe no banco"})
+			continue
+		}
+
+		expectedType := normalizeSQLType(dialect.ColumnType(field))
+		actualType := normalizeSQLType(dialect.ColumnType(current))
+		if expectedType != actualType {
+			drifts = append(drifts, SchemaDrift{Table: table, Column: column, Message: fmt.Sprintf("tipo esperado %s, encontrado %s", expectedType, actualType)})
+		}
+
+		expectedNotNull := field.IsNotNull || field.IsPrimaryKey
+		actualNotNull := current.IsNotNull || current.IsPrimaryKey
+		if expectedNotNull != actualNotNull {
+			drifts = append(drifts, SchemaDrift{Table: table, Column: column, Message: fmt.Sprintf("esperado %s, encontrado %s", nullability(expectedNotNull), nullability(actualNotNull))})
+		}
+
+		// O default de autoIncrement é a sequência ou o AUTO_INCREMENT do banco
+		if !field.AutoIncrement && !sameDefault(field.DefaultValue, current.DefaultValue) {
+			drifts = append(drifts, SchemaDrift{Table: table, Column: column, Message: fmt.Sprintf("default esperado %s, encontrado %s", describeDefault(field.DefaultValue), describeDefault(current.DefaultValue))})
+		}
+	}
+
+	for _, field := range actual.Fields {
+		if _, ok := findField(expected, columnName(field)); !ok {
+			drifts = append(drifts, SchemaDrift{Table: table, Column: columnName(field), Message: "coluna existe no banco, mas não é esperada"})
+		}
+	}
+
+	expectedKey := primaryKeyColumns(expected)
+	actualKey := primaryKeyColumns(actual)
+	if !sameColumns(expectedKey, actualKey) {
+		drifts = append(drifts, SchemaDrift{Table: table, Message: fmt.Sprintf("chave primária esperada (%s), encontrada (%s)", strings.Join(expectedKey, ", "), strings.Join(actualKey, ", "))})
+	}
+
+	actualIndexes := make(map[string]IndexInfo)
+	for _, index := range modelIndexes(actual) {
+		actualIndexes[strings.ToLower(index.Name)] = index
+	}
+
+	for _, index := range modelIndexes(expected) {
+		current, ok := actualIndexes[strings.ToLower(index.Name)]
+		if !ok {
+			drifts = append(drifts, SchemaDrift{Table: table, Message: fmt.Sprintf("índice %s não existe no banco", index.Name)})
+			continue
+		}
+
+		// A condição WHERE não é comparada: cada banco a reescreve à sua maneira
+		if index.Unique != current.Unique || !sameColumns(index.Columns, current.Columns) {
+			drifts = append(drifts, SchemaDrift{Table: table, Message: fmt.Sprintf("índice %s esperado como %s, encontrado %s", index.Name, describeIndex(index), describeIndex(current))})
+		}
+	}
+
+	actualForeignKeys := foreignKeysByName(actual.ForeignKeys)
+	for _, foreignKey := range expected.ForeignKeys {
+		current, ok := actualForeignKeys[strings.ToLower(foreignKey.Name)]
+		if !ok {
+			drifts = append(drifts, SchemaDrift{Table: table, Message: fmt.Sprintf("chave estrangeira %s não existe no banco", foreignKey.Name)})
+			continue
+		}
+
+		if !sameForeignKey(foreignKey, current) {
+			drifts = append(drifts, SchemaDrift{Table: table, Message: fmt.Sprintf("chave estrangeira %s esperada como %s, encontrada %s", foreignKey.Name, describeForeignKey(foreignKey), describeForeignKey(current))})
+		}
+	}
+
+	expectedForeignKeys := foreignKeysByName(expected.ForeignKeys)
+	for _, foreignKey := range actual.ForeignKeys {
+		if _, ok := expectedForeignKeys[strings.ToLower(foreignKey.Name)]; !ok {
+			drifts = append(drifts, SchemaDrift{Table: table, Message: fmt.Sprintf("chave estrangeira %s existe no banco, mas não é esperada", foreignKey.Name)})
+		}
+	}
+
+	return drifts
+}
+
+// sameDefault compara defaults como os bancos os devolvem: o MySQL grava true
+// como 1 e 0 em DECIMAL(10,2) como 0.00, e o SQLite mantém os parênteses.
+func sameDefault(expected, actual string) bool {
+	expected, actual = normalizeDefault(expected), normalizeDefault(actual)
+	if expected == actual {
+		return true
+	}
+
+	expectedNumber, expectedErr := strconv.ParseFloat(expected, 64)
+	actualNumber, actualErr := strconv.ParseFloat(actual, 64)
+	return expectedErr == nil && actualErr == nil && expectedNumber == actualNumber
+}
+
+func normalizeDefault(value string) string {
+	value = strings.TrimSpace(value)
+	for wrappedInParentheses(value) {
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+
+	// Strings mantêm maiúsculas e minúsculas; palavras-chave e funções não
+	if strings.HasPrefix(value, "'") {
+		return value
+	}
+
+	switch value = strings.ToUpper(value); value {
+	case "TRUE":
+		return "1"
+	case "FALSE":
+		return "0"
+	}
+
+	return value
+}
+
+// wrappedInParentheses indica se o valor inteiro está entre parênteses, como
+// em (0), mas não em (1) + (2).
+func wrappedInParentheses(value string) bool {
+	if len(value) < 2 || value[0] != '(' || value[len(value)-1] != ')' {
+		return false
+	}
+
+	depth := 0
+	for i, r := range value {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(value)-1 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func describeDefault(value string) string {
+	if value == "" {
+		return "nenhum"
+	}
+	return value
+}
+
+func describeForeignKey(foreignKey ForeignKeyInfo) string {
+	description := fmt.Sprintf("(%s) REFERENCES %s (%s)", strings.Join(foreignKey.Columns, ", "), foreignKey.RefTable, strings.Join(foreignKey.RefColumns, ", "))
+	if action := referentialAction(foreignKey.OnDelete); action != "NO ACTION" {
+		description += " ON DELETE " + action
+	}
+	if action := referentialAction(foreignKey.OnUpdate); action != "NO ACTION" {
+		description += " ON UPDATE " + action
+	}
+	return description
+}
+
+// normalizeSQLType deixa tipos equivalentes com a mesma grafia, por exemplo
+// "character varying(255)" e "VARCHAR(255)".
+func normalizeSQLType(sqlType string) string {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(sqlType), " "))
+	normalized = sqlTypeSpacePattern.ReplaceAllString(normalized, "$1")
+
+	if matches := timestampZonePattern.FindStringSubmatch(normalized); matches != nil {
+		return "TIMESTAMPTZ" + matches[1]
+	}
+
+	if matches := timestampNoZonePattern.FindStringSubmatch(normalized); matches != nil {
+		return "TIMESTAMP" + matches[1]
+	}
+
+	base, arguments := normalized, ""
+	if i := strings.Index(normalized, "("); i >= 0 {
+		base, arguments = normalized[:i], normalized[i:]
+	}
+
+	if synonym, ok := sqlTypeSynonyms[base]; ok {
+		base = synonym
+	}
+
+	return base + arguments
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+func describeIndex(index IndexInfo) string {
+	description := fmt.Sprintf("(%s)", strings.Join(index.Columns, ", "))
+	if index.Unique {
+		description = "UNIQUE " + description
+	}
+	return description
+}
+
+func sortedModels(models []ModelInfo) []ModelInfo {
+	sorted := append([]ModelInfo(nil), models...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].TableName) < strings.ToLower(sorted[j].TableName)
+	})
+	return sorted
+}
+
+// inspectedModels normaliza as tabelas lidas do banco da mesma forma que o
+// schema reconstruído das migrações, para que possam ser comparados.
+func inspectedModels(models []ModelInfo) []ModelInfo {
+	return newSchemaStateFromModels(models).models()
+}
+
+// inspectedAction descarta NO ACTION, a ação padrão, como nas chaves
+// estrangeiras geradas a partir dos models.
+func inspectedAction(action string) string {
+	action = strings.ToUpper(strings.TrimSpace(action))
+	if action == "NO ACTION" {
+		return ""
+	}
+	return action
+}
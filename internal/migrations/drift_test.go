@@ -0,0 +1,227 @@
+package migrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeSQLType(t *testing.T) {
+	tests := []struct {
+		sqlType string
+		want    string
+	}{
+		{"integer", "INTEGER"},
+		{"int4", "INTEGER"},
+		{"serial", "INTEGER"},
+		{"int8", "BIGINT"},
+		{"bigserial", "BIGINT"},
+		{"int2", "SMALLINT"},
+		{"bool", "BOOLEAN"},
+		{"float8", "DOUBLE PRECISION"},
+		{"decimal(10, 2)", "NUMERIC(10,2)"},
+		{"character varying(255)", "VARCHAR(255)"},
+		{"character  varying ( 255 )", "VARCHAR(255)"},
+		{"character(2)", "CHAR(2)"},
+		{"timestamp with time zone", "TIMESTAMPTZ"},
+		{"timestamp(6) with time zone", "TIMESTAMPTZ(6)"},
+		{"timestamp without time zone", "TIMESTAMP"},
+		{"timestamp(3) without time zone", "TIMESTAMP(3)"},
+		{"timestamptz", "TIMESTAMPTZ"},
+		{"jsonb", "JSONB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sqlType, func(t *testing.T) {
+			if got := normalizeSQLType(tt.sqlType); got != tt.want {
+				t.Errorf("normalizeSQLType(%q) = %q, esperado %q", tt.sqlType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameDefault(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		want     bool
+	}{
+		{"0", "(0)", true},
+		{"0", "((0))", true},
+		{"true", "1", true},
+		{"TRUE", "1", true},
+		{"false", "0", true},
+		{"0", "0.00", true},
+		{"10.5", "10.50", true},
+		{"'ativo'", "('ativo')", true},
+		{"CURRENT_TIMESTAMP", "current_timestamp", true},
+		{"", "", true},
+		{"'Ativo'", "'ativo'", false},
+		{"0", "1", false},
+		{"0", "", false},
+		{"(1) + (2)", "3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected+" vs "+tt.actual, func(t *testing.T) {
+			if got := sameDefault(tt.expected, tt.actual); got != tt.want {
+				t.Errorf("sameDefault(%q, %q) = %v, esperado %v", tt.expected, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSchema(t *testing.T) {
+	dialect := PostgresDialect{}
+
+	tests := []struct {
+		name   string
+		actual func(users, orders *ModelInfo) []ModelInfo
+		want   []SchemaDrift
+	}{
+		{
+			name:   "sem diferenças",
+			actual: func(users, orders *ModelInfo) []ModelInfo { return []ModelInfo{*users, *orders} },
+		},
+		{
+			name:   "tabela ausente",
+			actual: func(users, orders *ModelInfo) []ModelInfo { return []ModelInfo{*users} },
+			want:   []SchemaDrift{{Table: "orders", Message: "tabela não existe no banco"}},
+		},
+		{
+			name: "tabela sobrando e tabelas internas",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				return []ModelInfo{*users, *orders, {TableName: "legacy"}, {TableName: HistoryTable}, {TableName: LockTable}}
+			},
+			want: []SchemaDrift{{Table: "legacy", Message: "tabela existe no banco, mas não é esperada"}},
+		},
+		{
+			name: "coluna ausente e coluna sobrando",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				users.Fields[1].Column = "full_name"
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{
+				{Table: "users", Column: "name", Message: "coluna não existe no banco"},
+				{Table: "users", Column: "full_name", Message: "coluna existe no banco, mas não é esperada"},
+			},
+		},
+		{
+			name: "tipo",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				users.Fields[1].SQLType = "character varying(255)"
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "users", Column: "name", Message: "tipo esperado VARCHAR(100), encontrado VARCHAR(255)"}},
+		},
+		{
+			name: "nulidade",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				users.Fields[1].IsNotNull = false
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "users", Column: "name", Message: "esperado NOT NULL, encontrado NULL"}},
+		},
+		{
+			name: "default",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.Fields[2].DefaultValue = "1"
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "orders", Column: "total", Message: "default esperado 0, encontrado 1"}},
+		},
+		{
+			name: "default ausente",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.Fields[2].DefaultValue = ""
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "orders", Column: "total", Message: "default esperado 0, encontrado nenhum"}},
+		},
+		{
+			name: "default equivalente",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.Fields[2].DefaultValue = "(0.00)"
+				return []ModelInfo{*users, *orders}
+			},
+		},
+		{
+			name: "chave primária",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				users.Fields[0].IsPrimaryKey = false
+				users.Fields[0].IsNotNull = true
+				users.Fields[2].IsPrimaryKey = true
+				users.Fields[2].IsUnique = false
+				users.Indexes = []IndexInfo{{Name: "idx_users_email", Columns: []string{"email"}, Unique: true}}
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{
+				{Table: "users", Column: "email", Message: "esperado NULL, encontrado NOT NULL"},
+				{Table: "users", Message: "chave primária esperada (id), encontrada (email)"},
+			},
+		},
+		{
+			name: "índice ausente",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.Fields[1].IsIndex = false
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "orders", Message: "índice idx_orders_user_id não existe no banco"}},
+		},
+		{
+			name: "índice diferente",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				users.Fields[2].IsUnique = false
+				users.Indexes = []IndexInfo{{Name: "idx_users_email", Columns: []string{"email"}}}
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "users", Message: "índice idx_users_email esperado como UNIQUE (email), encontrado (email)"}},
+		},
+		{
+			name: "índice apenas no banco não é reportado",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.Indexes = []IndexInfo{{Name: "orders_user_id_fkey_idx", Columns: []string{"user_id"}}}
+				return []ModelInfo{*users, *orders}
+			},
+		},
+		{
+			name: "chave estrangeira ausente",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.ForeignKeys = nil
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "orders", Message: "chave estrangeira fk_orders_user não existe no banco"}},
+		},
+		{
+			name: "chave estrangeira diferente",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.ForeignKeys[0].OnDelete = "NO ACTION"
+				orders.ForeignKeys[0].OnUpdate = "CASCADE"
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{{Table: "orders", Message: "chave estrangeira fk_orders_user esperada como (user_id) REFERENCES users (id) ON DELETE CASCADE, encontrada (user_id) REFERENCES users (id) ON UPDATE CASCADE"}},
+		},
+		{
+			name: "chave estrangeira com outro nome",
+			actual: func(users, orders *ModelInfo) []ModelInfo {
+				orders.ForeignKeys[0].Name = "orders_user_id_fkey"
+				return []ModelInfo{*users, *orders}
+			},
+			want: []SchemaDrift{
+				{Table: "orders", Message: "chave estrangeira fk_orders_user não existe no banco"},
+				{Table: "orders", Message: "chave estrangeira orders_user_id_fkey existe no banco, mas não é esperada"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, orders := goldenUsers(), goldenOrders()
+			actual := tt.actual(&users, &orders)
+
+			got := CompareSchema(dialect, []ModelInfo{goldenUsers(), goldenOrders()}, actual)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareSchema =\n%v\nesperado\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// postgresDSNEnv aponta para um PostgreSQL de testes. Sem ela o teste é
+// ignorado. As tabelas são criadas em um schema próprio, removido no final.
+const postgresDSNEnv = "GAVER_TEST_POSTGRES_DSN"
+
+// runGoldenCasesOnDatabase aplica, para cada caso de goldenCases, a migração
+// do schema anterior e a do caso no banco retornado por open. Depois de cada
+// passo o schema do banco precisa bater com o dos models, e a seção down
+// precisa voltar ao schema anterior.
+func runGoldenCasesOnDatabase(t *testing.T, dialect Dialect, open func(t *testing.T, fn func(db *gorm.DB))) {
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			open(t, func(db *gorm.DB) {
+				dir := t.TempDir()
+
+				if tc.previous != nil {
+					initial := writeModelMigration(t, dialect, dir, 1, "initial", nil, tc.previous)
+					if err := ApplyMigration(db, dialect, initial); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				migration := writeModelMigration(t, dialect, dir, 2, tc.name, tc.previous, tc.current)
+				if err := ApplyMigration(db, dialect, migration); err != nil {
+					t.Fatal(err)
+				}
+				assertDatabaseSchema(t, dialect, db, tc.current)
+
+				if err := RevertMigration(db, dialect, migration); err != nil {
+					t.Fatal(err)
+				}
+				assertDatabaseSchema(t, dialect, db, tc.previous)
+			})
+		})
+	}
+}
+
+func assertDatabaseSchema(t *testing.T, dialect Dialect, db *gorm.DB, models []ModelInfo) {
+	t.Helper()
+
+	current, err := dialect.InspectSchema(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, drift := range CompareSchema(dialect, models, current) {
+		t.Errorf("diferença no banco: %s", drift)
+	}
+}
+
+func TestGoldenCasesOnSQLite(t *testing.T) {
+	runGoldenCasesOnDatabase(t, SQLiteDialect{}, func(t *testing.T, fn func(db *gorm.DB)) {
+		fn(openSQLite(t))
+	})
+}
+
+func TestGoldenCasesOnPostgres(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("defina %s para rodar os testes no PostgreSQL", postgresDSNEnv)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	runGoldenCasesOnDatabase(t, PostgresDialect{}, func(t *testing.T, fn func(db *gorm.DB)) {
+		schema := fmt.Sprintf("gaver_test_%d", os.Getpid())
+
+		// O search_path vale para a conexão: todos os comandos do caso usam a mesma
+		err := db.Connection(func(conn *gorm.DB) error {
+			conn = conn.Session(&gorm.Session{})
+			defer conn.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", schema))
+
+			if err := conn.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)).Error; err != nil {
+				return err
+			}
+			if err := conn.Exec(fmt.Sprintf("SET search_path TO %s", schema)).Error; err != nil {
+				return err
+			}
+			defer conn.Exec("RESET search_path")
+
+			if err := EnsureHistoryTable(conn); err != nil {
+				return err
+			}
+
+			fn(conn)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
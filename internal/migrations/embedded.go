@@ -0,0 +1,356 @@
+package migrations
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// Structs de pacotes externos que costumam ser embutidos nos models e cujo
+// código não está no projeto.
+var builtinPackageSources = map[string]string{
+	"gorm.io/gorm": `package gorm
+
+import "time"
+
+type Model struct {
+	ID        uint ` + "`gorm:\"primaryKey\"`" + `
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt ` + "`gorm:\"index\"`" + `
+}
+`,
+}
+
+// modelScanner mantém os pacotes já lidos, para resolver structs embutidos
+// declarados no mesmo pacote, em outros pacotes do projeto (ex.:
+// internal/engine/patterns) ou em builtinPackageSources.
+type modelScanner struct {
+	fset       *token.FileSet
+	modulePath string
+	packages   map[string]*scannedPackage
+	models     []*scannedModel
+	byStruct   map[*ast.StructType]*scannedModel
+	external   map[*ast.StructType]*scannedModel
+}
+
+type scannedPackage struct {
+	structs    []structDecl
+	byName     map[string]structDecl
+	types      map[string]typeDecl
+	tableNames map[string]string
+}
+
+type structDecl struct {
+	name string
+	spec *ast.StructType
+	file *ast.File
+	pkg  *scannedPackage
+}
+
+func newModelScanner() *modelScanner {
+	return &modelScanner{
+		fset:       token.NewFileSet(),
+		modulePath: readModulePath("go.mod"),
+		packages:   make(map[string]*scannedPackage),
+		byStruct:   make(map[*ast.StructType]*scannedModel),
+		external:   make(map[*ast.StructType]*scannedModel),
+	}
+}
+
+func readModulePath(goModPath string) string {
+	content, err := os.ReadFile(goModPath)
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), "\"")
+		}
+	}
+
+	return ""
+}
+
+// parsePackage lê todos os arquivos de um pacote antes de montar os models,
+// pois o método TableName() pode estar em um arquivo diferente do struct.
+func (s *modelScanner) parsePackage(dir string, filePaths []string) (*scannedPackage, error) {
+	key := filepath.Clean(dir)
+	if pkg, ok := s.packages[key]; ok {
+		return pkg, nil
+	}
+
+	var files []*ast.File
+	for _, filePath := range filePaths {
+		node, err := parser.ParseFile(s.fset, filePath, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao parsear arquivo %s: %w", filePath, err)
+		}
+		files = append(files, node)
+	}
+
+	pkg := newScannedPackage(files)
+	s.packages[key] = pkg
+	return pkg, nil
+}
+
+func newScannedPackage(files []*ast.File) *scannedPackage {
+	pkg := &scannedPackage{
+		byName:     make(map[string]structDecl),
+		types:      make(map[string]typeDecl),
+		tableNames: make(map[string]string),
+	}
+
+	for _, node := range files {
+		for name, tableName := range findTableNameMethods(node) {
+			pkg.tableNames[name] = tableName
+		}
+
+		for _, decl := range node.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					// Tipos nomeados como type Status string
+					pkg.types[ts.Name.Name] = typeDecl{expr: ts.Type, context: typeContext{pkg: pkg, file: node}}
+					continue
+				}
+
+				structDecl := structDecl{name: ts.Name.Name, spec: st, file: node, pkg: pkg}
+				pkg.structs = append(pkg.structs, structDecl)
+				pkg.byName[structDecl.name] = structDecl
+			}
+		}
+	}
+
+	return pkg
+}
+
+// importPackage carrega o pacote importado com o caminho informado. Apenas
+// pacotes do próprio módulo e os de builtinPackageSources podem ser lidos.
+func (s *modelScanner) importPackage(importPath string) (*scannedPackage, bool) {
+	if pkg, ok := s.packages[importPath]; ok {
+		return pkg, true
+	}
+
+	if source, ok := builtinPackageSources[importPath]; ok {
+		node, err := parser.ParseFile(s.fset, importPath+".go", source, 0)
+		if err != nil {
+			return nil, false
+		}
+
+		pkg := newScannedPackage([]*ast.File{node})
+		s.packages[importPath] = pkg
+		return pkg, true
+	}
+
+	if s.modulePath == "" || (importPath != s.modulePath && !strings.HasPrefix(importPath, s.modulePath+"/")) {
+		return nil, false
+	}
+
+	dir := filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(importPath, s.modulePath), "/"))
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+
+	var filePaths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		filePaths = append(filePaths, filepath.Join(dir, entry.Name()))
+	}
+
+	pkg, err := s.parsePackage(dir, filePaths)
+	if err != nil {
+		return nil, false
+	}
+
+	return pkg, true
+}
+
+// resolveStruct encontra a declaração do struct usado como tipo de um campo
+// embutido: Base, *Base ou pacote.Base.
+func (s *modelScanner) resolveStruct(owner structDecl, expr ast.Expr) (structDecl, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		decl, ok := owner.pkg.byName[t.Name]
+		return decl, ok
+	case *ast.SelectorExpr:
+		pkgName, ok := t.X.(*ast.Ident)
+		if !ok {
+			return structDecl{}, false
+		}
+
+		importPath, ok := findImport(owner.file, pkgName.Name)
+		if !ok {
+			return structDecl{}, false
+		}
+
+		pkg, ok := s.importPackage(importPath)
+		if !ok {
+			return structDecl{}, false
+		}
+
+		decl, ok := pkg.byName[t.Sel.Name]
+		return decl, ok
+	default:
+		return structDecl{}, false
+	}
+}
+
+// embeddedStructs retorna os structs do pacote que são embutidos em outros
+// structs do mesmo pacote. Eles fazem parte da tabela de quem os embute e não
+// têm tabela própria.
+func embeddedStructs(pkg *scannedPackage) map[string]bool {
+	embedded := make(map[string]bool)
+
+	for _, decl := range pkg.structs {
+		for _, field := range decl.spec.Fields.List {
+			if _, ok := gormTagSettings(field)["EMBEDDED"]; !ok && len(field.Names) > 0 {
+				continue
+			}
+
+			fieldType := field.Type
+			if star, ok := fieldType.(*ast.StarExpr); ok {
+				fieldType = star.X
+			}
+
+			if ident, ok := fieldType.(*ast.Ident); ok {
+				embedded[ident.Name] = true
+			}
+		}
+	}
+
+	return embedded
+}
+
+func findImport(file *ast.File, name string) (string, bool) {
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		importName := packageName(importPath)
+		if spec.Name != nil {
+			importName = spec.Name.Name
+		}
+
+		if importName == name {
+			return importPath, true
+		}
+	}
+
+	return "", false
+}
+
+// structFields achata os campos do struct na ordem de declaração, expandindo
+// structs embutidos (anônimos ou com gorm:"embedded") na posição em que
+// aparecem. embeddedPrefix é aplicado ao nome das colunas expandidas. Campos
+// de associação são retornados à parte.
+func (s *modelScanner) structFields(decl structDecl, prefix string, visiting map[*ast.StructType]bool) ([]FieldInfo, []relationField) {
+	if visiting[decl.spec] {
+		return nil, nil
+	}
+	visiting[decl.spec] = true
+	defer delete(visiting, decl.spec)
+
+	var fields []FieldInfo
+	var relations []relationField
+	var fromEmbedded []bool
+	direct := make(map[string]bool)
+
+	for _, field := range decl.spec.Fields.List {
+		gormSettings := gormTagSettings(field)
+		if ignoredField(field, gormSettings) {
+			continue
+		}
+
+		if _, embedded := gormSettings["EMBEDDED"]; embedded || len(field.Names) == 0 {
+			if embeddedDecl, ok := s.resolveStruct(decl, field.Type); ok {
+				embeddedFields, embeddedRelations := s.structFields(embeddedDecl, prefix+gormSettings["EMBEDDEDPREFIX"], visiting)
+				for _, embeddedField := range embeddedFields {
+					fields = append(fields, embeddedField)
+					fromEmbedded = append(fromEmbedded, true)
+				}
+				relations = append(relations, embeddedRelations...)
+				continue
+			}
+
+			if len(field.Names) == 0 {
+				continue
+			}
+		}
+
+		if target, many, ok := s.relationTarget(decl, field.Type, gormSettings); ok {
+			relations = append(relations, relationField{name: field.Names[0].Name, target: target, many: many, settings: gormSettings})
+			continue
+		}
+
+		fieldType, nullable := s.resolveFieldType(typeContext{pkg: decl.pkg, file: decl.file}, field.Type)
+		fieldInfo := parseField(field, fieldType, nullable)
+		if fieldInfo == nil {
+			continue
+		}
+
+		fieldInfo.Column = prefix + fieldInfo.Column
+		fields = append(fields, *fieldInfo)
+		fromEmbedded = append(fromEmbedded, false)
+		direct[strings.ToLower(fieldInfo.Column)] = true
+	}
+
+	// Como no GORM, um campo declarado no próprio struct prevalece sobre um
+	// campo de mesma coluna vindo de um struct embutido
+	var result []FieldInfo
+	for i, field := range fields {
+		if fromEmbedded[i] && direct[strings.ToLower(columnName(field))] {
+			continue
+		}
+		result = append(result, field)
+	}
+
+	return result, relations
+}
+
+func gormTagSettings(field *ast.Field) map[string]string {
+	return schema.ParseTagSetting(gormTag(field), ";")
+}
+
+// gormTag retorna o valor bruto da tag gorm do campo.
+func gormTag(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+
+	return reflect.StructTag(tag).Get("gorm")
+}
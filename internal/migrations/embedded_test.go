@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanEmbeddedStructs(t *testing.T) {
+	models, _ := scanSource(t, `package models
+
+import "gorm.io/gorm"
+
+type Audit struct {
+	CreatedBy string
+	UpdatedBy *string
+}
+
+type Address struct {
+	Street string
+	City   string
+}
+
+type Base struct {
+	ID   uint `+"`gorm:\"primaryKey\"`"+`
+	Name string
+}
+
+type Post struct {
+	gorm.Model
+	Title string
+}
+
+type Customer struct {
+	ID       uint
+	Audit
+	Billing  Address `+"`gorm:\"embedded;embeddedPrefix:billing_\"`"+`
+	Shipping Address `+"`gorm:\"embedded\"`"+`
+}
+
+type Tag struct {
+	*Base
+	Name string `+"`gorm:\"size:50\"`"+`
+}
+`)
+
+	tests := []struct {
+		table   string
+		columns []string
+	}{
+		{"posts", []string{"id", "created_at", "updated_at", "deleted_at", "title"}},
+		{"customers", []string{"id", "created_by", "updated_by", "billing_street", "billing_city", "street", "city"}},
+		// O campo declarado no próprio struct prevalece sobre o embutido
+		{"tags", []string{"id", "name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			model := findModel(t, models, tt.table)
+			if got := modelColumns(model); strings.Join(got, ",") != strings.Join(tt.columns, ",") {
+				t.Errorf("colunas = %v, esperado %v", got, tt.columns)
+			}
+		})
+	}
+
+	// Structs embutidos não têm tabela própria
+	for _, model := range models {
+		switch model.Name {
+		case "Audit", "Address", "Base":
+			t.Errorf("struct embutido %s virou a tabela %s", model.Name, model.TableName)
+		}
+	}
+
+	post := findModel(t, models, "posts")
+	if !findColumn(t, post, "id").IsPrimaryKey {
+		t.Error("posts.id deveria ser a chave primária vinda de gorm.Model")
+	}
+	if deletedAt := findColumn(t, post, "deleted_at"); deletedAt.IsNotNull || !deletedAt.IsIndex {
+		t.Errorf("posts.deleted_at deveria ser anulável e indexada: %+v", deletedAt)
+	}
+
+	customer := findModel(t, models, "customers")
+	if findColumn(t, customer, "updated_by").IsNotNull {
+		t.Error("customers.updated_by vem de *string e deveria ser anulável")
+	}
+
+	tag := findModel(t, models, "tags")
+	if name := findColumn(t, tag, "name"); name.Size != 50 {
+		t.Errorf("tags.name deveria ser a declarada em Tag, com size 50: %+v", name)
+	}
+	if !findColumn(t, tag, "id").IsPrimaryKey {
+		t.Error("tags.id deveria ser a chave primária vinda de *Base")
+	}
+}
@@ -15,8 +15,19 @@ type MigrationFile struct {
 	Number   int
 	Name     string
 	FullName string
+	// Migração em Go registrada com Register; nil para arquivos .sql
+	Go *GoMigration
+	// Migrações substituídas, quando o arquivo é um squash
+	Replaces []ReplacedMigration
+	// Arquivos originais do squash que ainda existem em migrations/
+	Replaced []MigrationFile
 }
 
+// ListMigrationFiles lista os arquivos .sql de migrations/ e as migrações em
+// Go registradas, ordenados pelo número. Os arquivos substituídos por um
+// squash não aparecem na lista, apenas em Replaced do squash. Um arquivo
+// <número>_<nome>.go sem a migração registrada é um erro: o pacote não foi
+// importado pelo executável e a migração seria ignorada em silêncio.
 func ListMigrationFiles(migrationsDir string, currentTag int) ([]MigrationFile, error) {
 	var files []MigrationFile
 
@@ -29,12 +40,20 @@ func ListMigrationFiles(migrationsDir string, currentTag int) ([]MigrationFile,
 	}
 
 	pattern := regexp.MustCompile(`^(\d{4})_(.+)\.sql$`)
+	goPattern := regexp.MustCompile(`^(\d{4})_(.+)\.go$`)
 
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
 
+		if matches := goPattern.FindStringSubmatch(entry.Name()); len(matches) == 3 && !strings.HasSuffix(entry.Name(), "_test.go") {
+			if err := checkRegisteredGoMigration(migrationsDir, entry.Name(), matches[1], matches[2], currentTag); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		matches := pattern.FindStringSubmatch(entry.Name())
 		if len(matches) != 3 {
 			continue
@@ -49,19 +68,50 @@ func ListMigrationFiles(migrationsDir string, currentTag int) ([]MigrationFile,
 			continue
 		}
 
-		files = append(files, MigrationFile{
+		migrationFile := MigrationFile{
 			Path:     filepath.Join(migrationsDir, entry.Name()),
 			Number:   migrationNumber,
 			Name:     matches[2],
 			FullName: entry.Name(),
-		})
+		}
+
+		if strings.HasPrefix(migrationFile.Name, squashedNamePrefix) {
+			migrationFile.Replaces, err = readReplaces(migrationFile.Path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		files = append(files, migrationFile)
 	}
 
-	sort.Slice(files, func(i, j int) bool {
+	files = append(files, registeredMigrationFiles(currentTag)...)
+
+	sort.SliceStable(files, func(i, j int) bool {
 		return files[i].Number < files[j].Number
 	})
 
-	return files, nil
+	return collapseSquashedMigrations(files), nil
+}
+
+// checkRegisteredGoMigration confere se o arquivo .go de migrations/ registrou
+// a migração de mesmo número e nome.
+func checkRegisteredGoMigration(migrationsDir, fileName, numberText, name string, currentTag int) error {
+	number, err := strconv.Atoi(numberText)
+	if err != nil || number <= currentTag {
+		return nil
+	}
+
+	migration, ok := goMigrations[number]
+	if !ok {
+		return fmt.Errorf("a migração em Go %s não está registrada. Importe o pacote no executável (import _ \"<module>/%s\") para que o init() chame migrations.Register", fileName, filepath.ToSlash(migrationsDir))
+	}
+
+	if migration.Name != name {
+		return fmt.Errorf("a migração %04d está registrada como %s, mas o arquivo se chama %s. Use o mesmo nome no arquivo e em migrations.Register", number, migration.Name, fileName)
+	}
+
+	return nil
 }
 
 func ReadMigrationFile(filePath string) (string, error) {
@@ -76,40 +126,88 @@ func ReadMigrationFile(filePath string) (string, error) {
 	return sql, nil
 }
 
-func SplitSQLStatements(sql string) []string {
-	statements := []string{}
-	lines := strings.Split(sql, "\n")
-	var currentStatement strings.Builder
-	
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		
-		if line == "" || strings.HasPrefix(line, "--") {
+const (
+	UpMarker   = "-- +up"
+	DownMarker = "-- +down"
+)
+
+// FormatMigration monta o conteúdo de um arquivo de migração com as seções
+// de avanço e de reversão.
+func FormatMigration(up, down string) string {
+	var content strings.Builder
+
+	content.WriteString(UpMarker + "\n")
+	content.WriteString(strings.TrimSpace(up))
+	content.WriteString("\n\n")
+	content.WriteString(DownMarker + "\n")
+	content.WriteString(strings.TrimSpace(down))
+	content.WriteString("\n")
+
+	return content.String()
+}
+
+// ParseMigrationSections separa as seções -- +up e -- +down de uma migração.
+// Arquivos sem marcadores são tratados como contendo apenas a seção up.
+func ParseMigrationSections(sql string) (up string, down string) {
+	var upSection, downSection strings.Builder
+	current := &upSection
+
+	for _, line := range strings.Split(sql, "\n") {
+		switch strings.TrimSpace(line) {
+		case UpMarker:
+			current = &upSection
+			continue
+		case DownMarker:
+			current = &downSection
 			continue
 		}
-		
-		currentStatement.WriteString(line)
-		currentStatement.WriteString(" ")
-		
-		if strings.HasSuffix(line, ";") {
-			stmt := strings.TrimSpace(currentStatement.String())
-			if stmt != "" && stmt != ";" {
-				statements = append(statements, stmt)
-			}
-			currentStatement.Reset()
+
+		if strings.HasPrefix(strings.TrimSpace(line), ReplacesMarker) {
+			continue
 		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
 	}
-	
-	if currentStatement.Len() > 0 {
-		stmt := strings.TrimSpace(currentStatement.String())
-		if stmt != "" {
-			if !strings.HasSuffix(stmt, ";") {
-				stmt += ";"
-			}
-			statements = append(statements, stmt)
-		}
+
+	return strings.TrimSpace(upSection.String()), strings.TrimSpace(downSection.String())
+}
+
+func ReadMigrationSections(filePath string) (up string, down string, err error) {
+	sql, err := ReadMigrationFile(filePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	up, down = ParseMigrationSections(sql)
+	return up, down, nil
+}
+
+// ReadMigrationStatements lê um arquivo de migração e retorna os comandos de
+// cada seção exatamente como serão executados.
+func ReadMigrationStatements(dialect Dialect, filePath string) (up []string, down []string, err error) {
+	upSQL, downSQL, err := ReadMigrationSections(filePath)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return statements
+	return SplitSQLStatements(dialect, upSQL), SplitSQLStatements(dialect, downSQL), nil
 }
 
+func FindMigrationFile(files []MigrationFile, number int) (MigrationFile, error) {
+	var found []MigrationFile
+	for _, file := range files {
+		if file.Number == number {
+			found = append(found, file)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return MigrationFile{}, fmt.Errorf("migração %04d não encontrada", number)
+	case 1:
+		return found[0], nil
+	default:
+		return MigrationFile{}, fmt.Errorf("existem %d arquivos com o número %04d", len(found), number)
+	}
+}
@@ -2,103 +2,401 @@ package migrations
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
-func GenerateSQLForSQLite(models []ModelInfo) string {
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	// Ordenação (ASC ou DESC) de cada coluna, na mesma posição de Columns
+	Sorts  []string `json:"sorts,omitempty"`
+	Unique bool     `json:"unique,omitempty"`
+	// Condição de índices parciais (tag where)
+	Where string `json:"where,omitempty"`
+}
+
+type ForeignKeyInfo struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"refTable"`
+	RefColumns []string `json:"refColumns"`
+	OnDelete   string   `json:"onDelete,omitempty"`
+	OnUpdate   string   `json:"onUpdate,omitempty"`
+}
+
+type CheckInfo struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+// Dialect concentra tudo o que é específico de um banco de dados na geração
+// de migrações. O scanner, o diff e os comandos trabalham apenas com esta
+// interface; um novo banco é suportado registrando uma implementação com
+// RegisterDialect.
+type Dialect interface {
+	// Name é o valor de ProjectDatabaseType no gaverModule.json
+	Name() string
+	ColumnType(field FieldInfo) string
+	QuoteIdentifier(name string) string
+	CreateTable(model ModelInfo) string
+	AlterTable(table TableDiff) string
+	DropTable(model ModelInfo) string
+	CreateIndex(index IndexInfo) string
+	DropIndex(index IndexInfo) string
+	ForeignKey(foreignKey ForeignKeyInfo) string
+	// SupportsTransactionalDDL indica se CREATE/ALTER/DROP podem ser desfeitos
+	// por rollback. No MySQL esses comandos fazem commit implícito.
+	SupportsTransactionalDDL() bool
+	// SupportsColumnComments indica se a tag comment é gravada no banco. Sem
+	// suporte, mudanças apenas no comentário não geram migração.
+	SupportsColumnComments() bool
+	// SupportsPartialIndexes indica se CREATE INDEX aceita WHERE. Sem suporte,
+	// a condição dos índices é ignorada.
+	SupportsPartialIndexes() bool
+	// SupportsBackslashEscapes indica se \ escapa caracteres dentro de
+	// strings. SplitSQLStatements usa essa informação para achar o fim delas.
+	SupportsBackslashEscapes() bool
+	// InspectSchema lê as tabelas do banco conectado, exceto as internas da
+	// ferramenta, no mesmo formato do schema reconstruído das migrações.
+	InspectSchema(db *gorm.DB) ([]ModelInfo, error)
+	// AcquireLock impede que outra execução aplique migrações ao mesmo tempo,
+	// esperando até timeout (ErrLockTimeout). Retorna a função que libera o
+	// bloqueio.
+	AcquireLock(db *gorm.DB, holder string, timeout time.Duration) (func() error, error)
+}
+
+var dialects = map[string]Dialect{}
+
+func RegisterDialect(dialect Dialect) {
+	dialects[dialect.Name()] = dialect
+}
+
+func SupportedDatabaseTypes() []string {
+	var names []string
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DialectFor retorna o dialeto do banco configurado em ProjectDatabaseType.
+func DialectFor(databaseType string) (Dialect, error) {
+	dialect, ok := dialects[databaseType]
+	if !ok {
+		return nil, fmt.Errorf("tipo de banco de dados não suportado: %s. Suportados: %s", databaseType, strings.Join(SupportedDatabaseTypes(), ", "))
+	}
+
+	return dialect, nil
+}
+
+// GenerateSQL converte as diferenças entre dois estados do schema em SQL no
+// dialeto informado: tabelas criadas (com seus índices), alterações em tabelas
+// existentes e por fim tabelas removidas. As tabelas são criadas depois das
+// tabelas que referenciam e removidas antes delas.
+func GenerateSQL(dialect Dialect, diff SchemaDiff) string {
 	var sql strings.Builder
 
-	for _, model := range models {
-		sql.WriteString(fmt.Sprintf("-- Migration for table: %s\n", model.TableName))
-		sql.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", model.TableName))
+	for _, table := range sortByDependencies(diff.CreatedTables) {
+		sql.WriteString(fmt.Sprintf("-- Migration for table: %s\n", table.TableName))
+		sql.WriteString(dialect.CreateTable(table))
+		sql.WriteString("\n")
 
-		var fields []string
-		var primaryKeys []string
-		var indexes []string
+		for _, index := range modelIndexes(table) {
+			sql.WriteString(dialect.CreateIndex(index) + "\n")
+		}
 
-		for _, field := range model.Fields {
-			fieldSQL := generateSQLiteField(field)
-			fields = append(fields, "    "+fieldSQL)
+		sql.WriteString("\n")
+	}
 
-			if field.IsPrimaryKey {
-				primaryKeys = append(primaryKeys, field.Name)
-			}
+	for _, table := range diff.AlteredTables {
+		sql.WriteString(fmt.Sprintf("-- Alter table: %s\n", table.Current.TableName))
+		sql.WriteString(dialect.AlterTable(table))
+		sql.WriteString("\n")
+	}
 
-			if field.IsIndex && !field.IsPrimaryKey {
-				indexes = append(indexes, fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s (%s);", model.TableName, strings.ToLower(field.Name), model.TableName, field.Name))
-			}
+	for _, table := range sortByDependents(diff.DroppedTables) {
+		sql.WriteString(fmt.Sprintf("-- Drop table: %s\n", table.TableName))
+		sql.WriteString(dialect.DropTable(table))
+		sql.WriteString("\n")
+	}
 
-			if field.IsUnique && !field.IsPrimaryKey {
-				indexes = append(indexes, fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS idx_%s_%s_unique ON %s (%s);", model.TableName, strings.ToLower(field.Name), model.TableName, field.Name))
-			}
+	return sql.String()
+}
+
+// sortByDependencies ordena as tabelas para que as referenciadas por chaves
+// estrangeiras venham antes, mantendo a ordem original entre tabelas
+// independentes. Referências circulares não são reordenadas.
+func sortByDependencies(models []ModelInfo) []ModelInfo {
+	return sortTables(models, func(model, other ModelInfo) bool {
+		return referencesTable(model, other.TableName)
+	})
+}
+
+// sortByDependents é a ordem inversa, usada ao remover tabelas: quem
+// referencia uma tabela é removido antes dela.
+func sortByDependents(models []ModelInfo) []ModelInfo {
+	return sortTables(models, func(model, other ModelInfo) bool {
+		return referencesTable(other, model.TableName)
+	})
+}
+
+// sortTables visita as tabelas na ordem original, emitindo antes de cada uma
+// as tabelas de que ela depende.
+func sortTables(models []ModelInfo, dependsOn func(model, other ModelInfo) bool) []ModelInfo {
+	var sorted []ModelInfo
+	visited := make(map[int]bool)
+
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
 		}
+		visited[i] = true
 
-		if len(primaryKeys) > 0 {
-			fields = append(fields, fmt.Sprintf("    PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+		for j := range models {
+			if j != i && dependsOn(models[i], models[j]) {
+				visit(j)
+			}
 		}
 
-		sql.WriteString(strings.Join(fields, ",\n"))
-		sql.WriteString("\n);\n\n")
+		sorted = append(sorted, models[i])
+	}
+
+	for i := range models {
+		visit(i)
+	}
 
-		for _, indexSQL := range indexes {
-			sql.WriteString(indexSQL + "\n")
+	return sorted
+}
+
+func referencesTable(model ModelInfo, tableName string) bool {
+	for _, foreignKey := range model.ForeignKeys {
+		if strings.EqualFold(foreignKey.RefTable, tableName) {
+			return true
 		}
+	}
+	return false
+}
 
-		sql.WriteString("\n")
+type createTableOptions struct {
+	// Nome usado no CREATE TABLE, quando diferente de model.TableName
+	tableName string
+	// Com chave primária de uma só coluna, a restrição fica na própria coluna
+	inlinePrimaryKey bool
+	// Texto após o parêntese final, ex.: ENGINE=InnoDB
+	suffix string
+}
+
+// createTableSQL monta um CREATE TABLE com colunas, chave primária, chaves
+// estrangeiras e checks. columnDefinition gera cada coluna no formato do dialeto.
+func createTableSQL(dialect Dialect, model ModelInfo, columnDefinition func(FieldInfo) string, options createTableOptions) string {
+	tableName := options.tableName
+	if tableName == "" {
+		tableName = model.TableName
+	}
+
+	var sql strings.Builder
+	sql.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", dialect.QuoteIdentifier(tableName)))
+
+	var definitions []string
+	for _, field := range model.Fields {
+		definitions = append(definitions, "    "+columnDefinition(field))
+	}
+
+	primaryKeys := primaryKeyColumns(model)
+	if len(primaryKeys) > 1 || (len(primaryKeys) == 1 && !options.inlinePrimaryKey) {
+		definitions = append(definitions, fmt.Sprintf("    PRIMARY KEY (%s)", quoteColumns(dialect, primaryKeys)))
 	}
 
+	for _, foreignKey := range model.ForeignKeys {
+		definitions = append(definitions, "    "+dialect.ForeignKey(foreignKey))
+	}
+
+	for _, check := range model.Checks {
+		definitions = append(definitions, "    "+checkSQL(dialect, check))
+	}
+
+	sql.WriteString(strings.Join(definitions, ",\n"))
+	sql.WriteString("\n)" + options.suffix + ";\n")
+
 	return sql.String()
 }
 
-func generateSQLiteField(field FieldInfo) string {
-	var parts []string
-	parts = append(parts, fmt.Sprintf("\"%s\"", strings.ToLower(field.Name)))
+func createIndexSQL(dialect Dialect, index IndexInfo, ifNotExists bool) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+
+	exists := ""
+	if ifNotExists {
+		exists = "IF NOT EXISTS "
+	}
+
+	var columns []string
+	for i, column := range index.Columns {
+		column = dialect.QuoteIdentifier(column)
+		if sort := indexSort(index, i); sort != "" {
+			column += " " + sort
+		}
+		columns = append(columns, column)
+	}
+
+	where := ""
+	if index.Where != "" && dialect.SupportsPartialIndexes() {
+		where = " WHERE " + index.Where
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX %s%s ON %s (%s)%s;", unique, exists, dialect.QuoteIdentifier(index.Name), dialect.QuoteIdentifier(index.Table), strings.Join(columns, ", "), where)
+}
+
+// indexSort retorna a ordenação da coluna i do índice, em maiúsculas.
+func indexSort(index IndexInfo, i int) string {
+	if i >= len(index.Sorts) {
+		return ""
+	}
+	return strings.ToUpper(strings.TrimSpace(index.Sorts[i]))
+}
+
+func foreignKeySQL(dialect Dialect, foreignKey ForeignKeyInfo) string {
+	sql := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		dialect.QuoteIdentifier(foreignKey.Name),
+		quoteColumns(dialect, foreignKey.Columns),
+		dialect.QuoteIdentifier(foreignKey.RefTable),
+		quoteColumns(dialect, foreignKey.RefColumns),
+	)
+
+	if foreignKey.OnDelete != "" {
+		sql += " ON DELETE " + foreignKey.OnDelete
+	}
+
+	if foreignKey.OnUpdate != "" {
+		sql += " ON UPDATE " + foreignKey.OnUpdate
+	}
+
+	return sql
+}
+
+func checkSQL(dialect Dialect, check CheckInfo) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", dialect.QuoteIdentifier(check.Name), check.Expression)
+}
+
+// numericType monta tipos decimais com a precisão e a escala das tags
+// precision e scale, ex.: NUMERIC(10,2).
+func numericType(name string, field FieldInfo) string {
+	if field.Scale > 0 {
+		return fmt.Sprintf("%s(%d,%d)", name, field.Precision, field.Scale)
+	}
+	return fmt.Sprintf("%s(%d)", name, field.Precision)
+}
 
-	sqlType := getSQLiteType(field)
-	parts = append(parts, sqlType)
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
 
-	if field.IsPrimaryKey {
-		parts = append(parts, "PRIMARY KEY")
+func quoteColumns(dialect Dialect, columns []string) string {
+	var quoted []string
+	for _, column := range columns {
+		quoted = append(quoted, dialect.QuoteIdentifier(column))
 	}
+	return strings.Join(quoted, ", ")
+}
+
+// modelIndexes retorna os índices da tabela: os de ModelInfo.Indexes (com
+// nome, compostos ou com opções) e os das tags index e unique sem opções,
+// nomeados idx_<tabela>_<coluna> como no GORM. Se a coluna tem as duas tags,
+// apenas o índice único é criado.
+func modelIndexes(model ModelInfo) []IndexInfo {
+	var indexes []IndexInfo
 
-	if field.IsNotNull && !field.IsPrimaryKey {
-		parts = append(parts, "NOT NULL")
+	named := make(map[string]bool)
+	for _, index := range model.Indexes {
+		named[strings.ToLower(index.Name)] = true
 	}
 
-	if field.DefaultValue != "" && !field.IsPrimaryKey {
-		parts = append(parts, fmt.Sprintf("DEFAULT %s", field.DefaultValue))
+	for _, field := range model.Fields {
+		if field.IsPrimaryKey {
+			continue
+		}
+
+		column := columnName(field)
+
+		if field.IsIndex && !field.IsUnique {
+			indexes = append(indexes, IndexInfo{
+				Name:    columnIndexName(model.TableName, column),
+				Table:   model.TableName,
+				Columns: []string{column},
+			})
+		}
+
+		if field.IsUnique {
+			indexes = append(indexes, IndexInfo{
+				Name:    columnIndexName(model.TableName, column),
+				Table:   model.TableName,
+				Columns: []string{column},
+				Unique:  true,
+			})
+		}
 	}
 
-	return strings.Join(parts, " ")
+	// Um índice declarado com nome prevalece sobre o da tag simples
+	var result []IndexInfo
+	for _, index := range indexes {
+		if !named[strings.ToLower(index.Name)] {
+			result = append(result, index)
+		}
+	}
+
+	for _, index := range model.Indexes {
+		index.Table = model.TableName
+		result = append(result, index)
+	}
+
+	return result
 }
 
-func getSQLiteType(field FieldInfo) string {
-	if field.SQLType != "" {
-		return field.SQLType
+func columnIndexName(tableName, column string) string {
+	return namingStrategy.IndexName(tableName, column)
+}
+
+func primaryKeyColumns(model ModelInfo) []string {
+	var columns []string
+	for _, field := range model.Fields {
+		if field.IsPrimaryKey {
+			columns = append(columns, columnName(field))
+		}
 	}
+	return columns
+}
 
-	switch field.Type {
-	case "string":
-		return "TEXT"
-	case "int", "int32", "int64":
-		return "INTEGER"
-	case "uint", "uint32", "uint64":
-		return "INTEGER"
-	case "float32", "float64":
-		return "REAL"
-	case "bool", "boolean":
-		return "INTEGER"
-	case "time.Time":
-		return "TEXT"
-	case "uuid.UUID":
-		return "TEXT"
-	case "gorm.DeletedAt":
-		return "TEXT"
-	default:
-		if strings.Contains(field.Type, "[]") {
-			return "TEXT"
+func primaryKeyChanged(table TableDiff) bool {
+	previous := primaryKeyColumns(table.Previous)
+	current := primaryKeyColumns(table.Current)
+
+	if len(previous) != len(current) {
+		return true
+	}
+
+	for i := range previous {
+		if !strings.EqualFold(previous[i], current[i]) {
+			return true
 		}
-		return "TEXT"
 	}
+
+	return false
 }
 
+// columnName retorna o nome da coluna no banco. Snapshots gerados antes do
+// campo Column usavam o nome do campo em minúsculas.
+func columnName(field FieldInfo) string {
+	if field.Column != "" {
+		return field.Column
+	}
+	return strings.ToLower(field.Name)
+}
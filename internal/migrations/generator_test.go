@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "regrava os arquivos .golden com o SQL gerado")
+
+func goldenUsers() ModelInfo {
+	return ModelInfo{
+		Name:      "User",
+		TableName: "users",
+		Fields: []FieldInfo{
+			{Name: "ID", Column: "id", Type: "uint", IsPrimaryKey: true, AutoIncrement: true},
+			{Name: "Name", Column: "name", Type: "string", IsNotNull: true, Size: 100},
+			{Name: "Email", Column: "email", Type: "string", IsUnique: true, Size: 150},
+			{Name: "CreatedAt", Column: "created_at", Type: "time.Time"},
+		},
+	}
+}
+
+func goldenOrders() ModelInfo {
+	return ModelInfo{
+		Name:      "Order",
+		TableName: "orders",
+		Fields: []FieldInfo{
+			{Name: "ID", Column: "id", Type: "uint", IsPrimaryKey: true, AutoIncrement: true},
+			{Name: "UserID", Column: "user_id", Type: "uint", IsNotNull: true, IsIndex: true},
+			{Name: "Total", Column: "total", Type: "float64", IsNotNull: true, Precision: 10, Scale: 2, DefaultValue: "0"},
+		},
+		ForeignKeys: []ForeignKeyInfo{
+			{Name: "fk_orders_user", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+		},
+	}
+}
+
+func goldenCases() []struct {
+	name     string
+	previous []ModelInfo
+	current  []ModelInfo
+} {
+	withPhone := goldenUsers()
+	withPhone.Fields = append(withPhone.Fields,
+		FieldInfo{Name: "Phone", Column: "phone", Type: "string", Size: 20},
+		FieldInfo{Name: "Active", Column: "active", Type: "bool", IsNotNull: true, DefaultValue: "true"},
+	)
+
+	withRequired := goldenUsers()
+	withRequired.Fields = append(withRequired.Fields,
+		FieldInfo{Name: "ExternalID", Column: "external_id", Type: "uuid.UUID", IsNotNull: true},
+		FieldInfo{Name: "Settings", Column: "settings", Type: "datatypes.JSON", IsNotNull: true},
+	)
+
+	altered := goldenUsers()
+	altered.Fields[1].Size = 255
+	altered.Fields[1].IsNotNull = false
+	altered.Fields[2].IsNotNull = true
+
+	previousIndexes := goldenUsers()
+	previousIndexes.Indexes = []IndexInfo{
+		{Name: "idx_users_created_at", Table: "users", Columns: []string{"created_at"}},
+	}
+	currentIndexes := goldenUsers()
+	currentIndexes.Indexes = []IndexInfo{
+		{Name: "idx_users_name_email", Table: "users", Columns: []string{"name", "email"}, Unique: true},
+	}
+
+	withoutForeignKey := goldenOrders()
+	withoutForeignKey.ForeignKeys = nil
+
+	withoutAutoIncrement := goldenOrders()
+	withoutAutoIncrement.Fields[0].AutoIncrement = false
+
+	withCheck := goldenUsers()
+	withCheck.Checks = []CheckInfo{{Name: "chk_users_name", Expression: "name <> ''"}}
+
+	return []struct {
+		name     string
+		previous []ModelInfo
+		current  []ModelInfo
+	}{
+		{"create_table", nil, []ModelInfo{goldenOrders(), goldenUsers()}},
+		{"drop_table", []ModelInfo{goldenUsers(), goldenOrders()}, nil},
+		{"add_column", []ModelInfo{goldenUsers()}, []ModelInfo{withPhone}},
+		{"add_not_null_column", []ModelInfo{goldenUsers()}, []ModelInfo{withRequired}},
+		{"alter_column", []ModelInfo{goldenUsers()}, []ModelInfo{altered}},
+		{"index", []ModelInfo{previousIndexes}, []ModelInfo{currentIndexes}},
+		{"foreign_key", []ModelInfo{goldenUsers(), withoutForeignKey}, []ModelInfo{goldenUsers(), goldenOrders()}},
+		{"add_auto_increment", []ModelInfo{goldenUsers(), withoutAutoIncrement}, []ModelInfo{goldenUsers(), goldenOrders()}},
+		{"drop_auto_increment", []ModelInfo{goldenUsers(), goldenOrders()}, []ModelInfo{goldenUsers(), withoutAutoIncrement}},
+		{"add_check", []ModelInfo{goldenUsers()}, []ModelInfo{withCheck}},
+		{"drop_check", []ModelInfo{withCheck}, []ModelInfo{goldenUsers()}},
+	}
+}
+
+// TestGenerateSQL compara o SQL gerado por cada dialeto com os arquivos em
+// testdata/generate_sql. Rode go test -update para regravá-los depois de uma
+// mudança intencional e revise o diff.
+func TestGenerateSQL(t *testing.T) {
+	dialects := []Dialect{SQLiteDialect{}, PostgresDialect{}, MySQLDialect{}}
+
+	for _, dialect := range dialects {
+		for _, tc := range goldenCases() {
+			t.Run(dialect.Name()+"/"+tc.name, func(t *testing.T) {
+				got := GenerateSQL(dialect, DiffModels(tc.previous, tc.current, dialect))
+				path := filepath.Join("testdata", "generate_sql", dialect.Name(), tc.name+".golden")
+
+				if *updateGolden {
+					if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("erro ao ler %s (rode go test -update para criá-lo): %v", path, err)
+				}
+
+				if got != string(want) {
+					t.Errorf("SQL gerado difere de %s\n--- obtido ---\n%s\n--- esperado ---\n%s", path, got, want)
+				}
+			})
+		}
+	}
+}
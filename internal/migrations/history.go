@@ -0,0 +1,236 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const HistoryTable = "gaver_migrations"
+
+// AppliedMigration é o registro de uma migração executada no banco. O estado
+// aplicado fica junto ao banco de dados e não no gaverModule.json.
+type AppliedMigration struct {
+	Number    int       `gorm:"column:number;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name;not null"`
+	Checksum  string    `gorm:"column:checksum;not null"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null"`
+	Duration  int64     `gorm:"column:duration;not null"` // em milissegundos
+}
+
+func (AppliedMigration) TableName() string {
+	return HistoryTable
+}
+
+func EnsureHistoryTable(db *gorm.DB) error {
+	if err := db.AutoMigrate(&AppliedMigration{}); err != nil {
+		return fmt.Errorf("erro ao criar tabela %s: %w", HistoryTable, err)
+	}
+
+	return nil
+}
+
+func ListAppliedMigrations(db *gorm.DB) ([]AppliedMigration, error) {
+	var applied []AppliedMigration
+	if err := db.Order("number").Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("erro ao ler tabela %s: %w", HistoryTable, err)
+	}
+
+	return applied, nil
+}
+
+// ListAppliedMigrationsIfExists lê gaver_migrations sem criá-la, para
+// comandos que apenas consultam o banco. Sem a tabela, nenhuma migração foi
+// aplicada.
+func ListAppliedMigrationsIfExists(db *gorm.DB) ([]AppliedMigration, error) {
+	if !db.Migrator().HasTable(HistoryTable) {
+		return nil, nil
+	}
+
+	return ListAppliedMigrations(db)
+}
+
+func RecordMigration(db *gorm.DB, migrationFile MigrationFile, checksum string, duration time.Duration) error {
+	record := AppliedMigration{
+		Number:    migrationFile.Number,
+		Name:      migrationFile.Name,
+		Checksum:  checksum,
+		AppliedAt: time.Now(),
+		Duration:  duration.Milliseconds(),
+	}
+
+	if err := db.Create(&record).Error; err != nil {
+		return fmt.Errorf("erro ao registrar migração %s: %w", migrationFile.FullName, err)
+	}
+
+	return nil
+}
+
+// LegacyAppliedMigrations monta os registros das migrações de 1 a
+// migrationTag. Antes de gaver_migrations, o migrate guardava em migrationTag
+// o número da última migração aplicada, e bancos migrados dessa forma têm o
+// histórico vazio.
+func LegacyAppliedMigrations(files []MigrationFile, migrationTag int) ([]AppliedMigration, error) {
+	var applied []AppliedMigration
+	for _, file := range files {
+		checksum, err := MigrationChecksum(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, migration := range coveredMigrations(file) {
+			if migration.Number > migrationTag {
+				continue
+			}
+
+			applied = append(applied, AppliedMigration{
+				Number:    migration.Number,
+				Name:      migration.Name,
+				Checksum:  checksum,
+				AppliedAt: time.Now(),
+			})
+		}
+	}
+
+	return applied, nil
+}
+
+// RecordAppliedMigrations grava de uma vez registros montados fora do migrate,
+// como os de LegacyAppliedMigrations.
+func RecordAppliedMigrations(db *gorm.DB, applied []AppliedMigration) error {
+	if len(applied) == 0 {
+		return nil
+	}
+
+	if err := db.Create(&applied).Error; err != nil {
+		return fmt.Errorf("erro ao registrar migrações em %s: %w", HistoryTable, err)
+	}
+
+	return nil
+}
+
+func RemoveMigrationRecord(db *gorm.DB, number int) error {
+	if err := db.Where("number = ?", number).Delete(&AppliedMigration{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover registro da migração %04d: %w", number, err)
+	}
+
+	return nil
+}
+
+// RenumberMigrationRecord atualiza o número de uma migração já aplicada cujo
+// arquivo foi renumerado.
+func RenumberMigrationRecord(db *gorm.DB, migration RenumberedMigration) error {
+	result := db.Model(&AppliedMigration{}).
+		Where("number = ? AND name = ?", migration.Previous.Number, migration.Previous.Name).
+		Update("number", migration.Current.Number)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao atualizar registro da migração %s: %w", migration.Previous.FullName, result.Error)
+	}
+
+	return nil
+}
+
+// PendingMigrations retorna os arquivos ainda não registrados como aplicados,
+// incluindo números menores que o último aplicado (ex.: vindos de outra branch).
+// Um squash fica pendente enquanto alguma das migrações que substitui não
+// estiver registrada.
+func PendingMigrations(files []MigrationFile, applied []AppliedMigration) []MigrationFile {
+	appliedNumbers := make(map[int]bool)
+	for _, migration := range applied {
+		appliedNumbers[migration.Number] = true
+	}
+
+	var pending []MigrationFile
+	for _, file := range files {
+		if !migrationApplied(file, appliedNumbers) {
+			pending = append(pending, file)
+		}
+	}
+
+	return pending
+}
+
+type ChecksumMismatch struct {
+	Number   int
+	Name     string
+	Recorded string
+	Current  string
+	Missing  bool
+}
+
+func (m ChecksumMismatch) String() string {
+	if m.Missing {
+		return fmt.Sprintf("%04d_%s: aplicada no banco, mas o arquivo não existe mais", m.Number, m.Name)
+	}
+
+	return fmt.Sprintf("%04d_%s: alterada após ser aplicada\n    registrado: %s\n    atual:      %s", m.Number, m.Name, m.Recorded, m.Current)
+}
+
+// VerifyChecksums compara o SHA-256 registrado de cada migração aplicada com
+// o conteúdo atual do arquivo correspondente. Migrações substituídas por um
+// squash podem corresponder tanto ao squash quanto ao arquivo original.
+func VerifyChecksums(files []MigrationFile, applied []AppliedMigration) ([]ChecksumMismatch, error) {
+	filesByNumber := make(map[int]MigrationFile)
+	for _, file := range files {
+		for _, migration := range coveredMigrations(file) {
+			filesByNumber[migration.Number] = file
+		}
+	}
+
+	var mismatches []ChecksumMismatch
+	for _, migration := range applied {
+		file, ok := filesByNumber[migration.Number]
+		if !ok {
+			mismatches = append(mismatches, ChecksumMismatch{
+				Number:   migration.Number,
+				Name:     migration.Name,
+				Recorded: migration.Checksum,
+				Missing:  true,
+			})
+			continue
+		}
+
+		checksum, err := MigrationChecksum(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if checksum == migration.Checksum {
+			continue
+		}
+
+		if len(file.Replaces) > 0 {
+			matches, err := replacedChecksumMatches(file, migration)
+			if err != nil {
+				return nil, err
+			}
+
+			if matches {
+				continue
+			}
+		}
+
+		mismatches = append(mismatches, ChecksumMismatch{
+			Number:   migration.Number,
+			Name:     migration.Name,
+			Recorded: migration.Checksum,
+			Current:  checksum,
+		})
+	}
+
+	return mismatches, nil
+}
+
+func FileChecksum(filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("erro ao ler arquivo de migração: %w", err)
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestListAppliedMigrationsIfExists(t *testing.T) {
+	db := openEmptySQLite(t)
+
+	applied, err := ListAppliedMigrationsIfExists(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("aplicadas = %v, esperado nenhuma", applied)
+	}
+	if db.Migrator().HasTable(HistoryTable) {
+		t.Errorf("%s não deveria ser criada por uma leitura", HistoryTable)
+	}
+
+	if err := EnsureHistoryTable(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordMigration(db, MigrationFile{Number: 1, Name: "initial", FullName: "0001_initial.sql"}, "abc", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err = ListAppliedMigrationsIfExists(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 || applied[0].Number != 1 {
+		t.Errorf("aplicadas = %+v, esperado 0001_initial", applied)
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	dir := t.TempDir()
+
+	unchanged := writeMigration(t, dir, 1, "initial", "-- +up\nCREATE TABLE a (id INTEGER);\n")
+	changed := writeMigration(t, dir, 2, "orders", "-- +up\nCREATE TABLE b (id INTEGER);\n")
+	removed := writeMigration(t, dir, 3, "removed", "-- +up\nCREATE TABLE c (id INTEGER);\n")
+
+	checksums := make(map[int]string)
+	for _, file := range []MigrationFile{unchanged, changed, removed} {
+		checksum, err := MigrationChecksum(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checksums[file.Number] = checksum
+	}
+
+	if err := os.WriteFile(changed.Path, []byte("-- +up\nCREATE TABLE b (id INTEGER, name TEXT);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(removed.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied := []AppliedMigration{
+		{Number: 1, Name: "initial", Checksum: checksums[1]},
+		{Number: 2, Name: "orders", Checksum: checksums[2]},
+		{Number: 3, Name: "removed", Checksum: checksums[3]},
+	}
+
+	mismatches, err := VerifyChecksums(files, applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := MigrationChecksum(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ChecksumMismatch{
+		{Number: 2, Name: "orders", Recorded: checksums[2], Current: current},
+		{Number: 3, Name: "removed", Recorded: checksums[3], Missing: true},
+	}
+	if !reflect.DeepEqual(mismatches, want) {
+		t.Errorf("divergências = %+v, esperado %+v", mismatches, want)
+	}
+}
+
+// Um squash confere tanto com o próprio checksum quanto com o dos arquivos
+// originais que ainda existem.
+func TestVerifyChecksumsSquash(t *testing.T) {
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+	writeSquashOriginals(t, dialect, dir)
+
+	originals, err := ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalChecksum, err := MigrationChecksum(originals[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := writeSquash(t, dialect, dir)
+	squashChecksum, err := MigrationChecksum(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied := []AppliedMigration{
+		{Number: 1, Name: "initial", Checksum: originalChecksum},
+		{Number: 2, Name: "orders", Checksum: squashChecksum},
+		{Number: 3, Name: "user_phone", Checksum: "alterado"},
+	}
+
+	mismatches, err := VerifyChecksums(files, applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 1 || mismatches[0].Number != 3 {
+		t.Errorf("divergências = %+v, esperado apenas a 0003", mismatches)
+	}
+}
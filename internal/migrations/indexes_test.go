@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldIndexes(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want []fieldIndex
+	}{
+		{
+			name: "tags sem opções ficam com parseGormTag",
+			tag:  "index;uniqueIndex;size:20",
+			want: nil,
+		},
+		{
+			name: "índice com nome",
+			tag:  "index:idx_name",
+			want: []fieldIndex{{name: "idx_name", priority: 10}},
+		},
+		{
+			name: "uniqueIndex com nome",
+			tag:  "uniqueIndex:idx_code",
+			want: []fieldIndex{{name: "idx_code", unique: true, priority: 10}},
+		},
+		{
+			name: "opções",
+			tag:  "index:idx_status,sort:desc,where:deleted_at IS NULL,priority:2",
+			want: []fieldIndex{{name: "idx_status", sort: "DESC", where: "deleted_at IS NULL", priority: 2}},
+		},
+		{
+			name: "unique e class:UNIQUE",
+			tag:  "index:idx_a,unique;index:idx_b,class:UNIQUE",
+			want: []fieldIndex{{name: "idx_a", unique: true, priority: 10}, {name: "idx_b", unique: true, priority: 10}},
+		},
+		{
+			name: "sem nome, apenas com opções",
+			tag:  "index:,sort:asc;uniqueIndex:,composite:tenant",
+			want: []fieldIndex{{sort: "ASC", priority: 10}, {composite: "tenant", unique: true, priority: 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseFieldIndexes(tt.tag)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldIndexes(%q)\n got: %+v\nwant: %+v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanIndexes(t *testing.T) {
+	models, _ := scanSource(t, `package models
+
+type Event struct {
+	ID        uint
+	TenantID  uint   `+"`gorm:\"index:idx_tenant_kind,priority:2;uniqueIndex:,composite:tenant_code\"`"+`
+	Kind      string `+"`gorm:\"index:idx_tenant_kind,priority:1\"`"+`
+	Code      string `+"`gorm:\"uniqueIndex:,composite:tenant_code\"`"+`
+	CreatedAt int64  `+"`gorm:\"index:,sort:desc\"`"+`
+	Status    string `+"`gorm:\"index:idx_open,where:status <> 'fechado'\"`"+`
+	Email     string `+"`gorm:\"index:idx_email,class:UNIQUE\"`"+`
+	Slug      string `+"`gorm:\"index;unique\"`"+`
+}
+`)
+
+	event := findModel(t, models, "events")
+
+	want := []IndexInfo{
+		// Os campos com o mesmo nome formam um único índice, ordenado pela priority
+		{Name: "idx_tenant_kind", Table: "events", Columns: []string{"kind", "tenant_id"}},
+		{Name: "idx_events_tenant_code", Table: "events", Columns: []string{"tenant_id", "code"}, Unique: true},
+		{Name: "idx_events_created_at", Table: "events", Columns: []string{"created_at"}, Sorts: []string{"DESC"}},
+		{Name: "idx_open", Table: "events", Columns: []string{"status"}, Where: "status <> 'fechado'"},
+		{Name: "idx_email", Table: "events", Columns: []string{"email"}, Unique: true},
+	}
+	if !reflect.DeepEqual(event.Indexes, want) {
+		t.Errorf("índices =\n%+v\nesperado\n%+v", event.Indexes, want)
+	}
+
+	// index e unique sem opções geram apenas o índice único com o nome do GORM
+	got := modelIndexes(event)
+	slug := got[0]
+	if len(got) != len(want)+1 || slug.Name != "idx_events_slug" || !slug.Unique || !reflect.DeepEqual(slug.Columns, []string{"slug"}) {
+		t.Errorf("modelIndexes = %+v, esperado idx_events_slug único seguido dos índices da tag", got)
+	}
+}
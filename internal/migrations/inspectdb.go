@@ -0,0 +1,480 @@
+package migrations
+
+import (
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jinzhu/inflection"
+)
+
+// GeneratedModel é o código de um model gerado a partir de uma tabela
+// existente pelo inspectdb.
+type GeneratedModel struct {
+	TableName  string
+	StructName string
+	FileName   string
+	Source     []byte
+}
+
+var (
+	sqlTypeNamePattern = regexp.MustCompile(`^[A-Z]+`)
+
+	commonInitialisms = map[string]bool{
+		"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true,
+		"JSON": true, "SQL": true, "URL": true, "UUID": true,
+	}
+
+	// Sufixos que o go build interpreta no nome do arquivo (_test, _linux...)
+	buildFileSuffixes = map[string]bool{
+		"test": true, "aix": true, "android": true, "darwin": true, "dragonfly": true,
+		"freebsd": true, "hurd": true, "illumos": true, "ios": true, "js": true,
+		"linux": true, "netbsd": true, "openbsd": true, "plan9": true, "solaris": true,
+		"wasip1": true, "windows": true, "zos": true, "386": true, "amd64": true,
+		"arm": true, "arm64": true, "loong64": true, "mips": true, "mipsle": true,
+		"mips64": true, "mips64le": true, "ppc64": true, "ppc64le": true,
+		"riscv64": true, "s390x": true, "wasm": true,
+	}
+
+	// Métodos de patterns.Model, que não podem ser usados como nome de campo
+	modelMethods = []string{"TableName", "Validate", "ToDTO"}
+)
+
+type generatedField struct {
+	name   string
+	goType string
+	tags   []string
+}
+
+// GenerateModels gera um struct por tabela, com TableName(), Validate() e
+// ToDTO() para satisfazer patterns.Model. Os tipos Go seguem o mapeamento do
+// dialeto e, quando ele não reproduz o tipo da coluna, a tag type é usada.
+// Chaves estrangeiras viram associações belongs to; as que apontam para
+// tabelas fora de models são retornadas como avisos.
+func GenerateModels(dialect Dialect, models []ModelInfo) ([]GeneratedModel, []string, error) {
+	modulePath := readModulePath("go.mod")
+	if modulePath == "" {
+		return nil, nil, fmt.Errorf("declaração module não encontrada em go.mod")
+	}
+
+	structNames := make(map[string]string)
+	usedStructs := make(map[string]bool)
+	for _, model := range models {
+		name := uniqueIdentifier(usedStructs, goIdentifier(inflection.Singular(model.TableName)))
+		structNames[strings.ToLower(model.TableName)] = name
+	}
+
+	fieldNames := make(map[string]map[string]string)
+	for _, model := range models {
+		fieldNames[strings.ToLower(model.TableName)] = columnFieldNames(model)
+	}
+
+	var generated []GeneratedModel
+	var warnings []string
+
+	for _, model := range models {
+		structName := structNames[strings.ToLower(model.TableName)]
+		names := fieldNames[strings.ToLower(model.TableName)]
+		fields := generatedFields(dialect, model, names)
+
+		used := reservedFieldNames()
+		for _, name := range names {
+			used[name] = true
+		}
+
+		for _, foreignKey := range model.ForeignKeys {
+			targetStruct, ok := structNames[strings.ToLower(foreignKey.RefTable)]
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("%s: chave estrangeira %s não gerada, a tabela %s não faz parte dos models gerados", model.TableName, foreignKey.Name, foreignKey.RefTable))
+				continue
+			}
+
+			fields = append(fields, belongsToField(foreignKey, targetStruct, names, fieldNames[strings.ToLower(foreignKey.RefTable)], used))
+		}
+
+		source, err := modelSource(structName, model.TableName, fields, modulePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao gerar model da tabela %s: %w", model.TableName, err)
+		}
+
+		generated = append(generated, GeneratedModel{
+			TableName:  model.TableName,
+			StructName: structName,
+			FileName:   modelFileName(model.TableName),
+			Source:     source,
+		})
+	}
+
+	return generated, warnings, nil
+}
+
+func reservedFieldNames() map[string]bool {
+	used := make(map[string]bool)
+	for _, method := range modelMethods {
+		used[method] = true
+	}
+	return used
+}
+
+// columnFieldNames dá um nome de campo Go, único no struct, a cada coluna.
+func columnFieldNames(model ModelInfo) map[string]string {
+	used := reservedFieldNames()
+	names := make(map[string]string)
+	for _, field := range model.Fields {
+		names[strings.ToLower(columnName(field))] = uniqueIdentifier(used, goIdentifier(columnName(field)))
+	}
+	return names
+}
+
+func generatedFields(dialect Dialect, model ModelInfo, names map[string]string) []generatedField {
+	var fields []generatedField
+
+	for _, field := range model.Fields {
+		column := columnName(field)
+		name := names[strings.ToLower(column)]
+
+		goType := inspectedGoType(dialect, field)
+		nullable := !field.IsNotNull && !field.IsPrimaryKey
+
+		var tags []string
+		if namingStrategy.ColumnName("", name) != column {
+			tags = append(tags, "column:"+column)
+		}
+
+		natural := FieldInfo{
+			Type:          goType,
+			IsPrimaryKey:  field.IsPrimaryKey,
+			IsUnique:      field.IsUnique,
+			IsIndex:       field.IsIndex,
+			InIndex:       inModelIndex(model, column),
+			AutoIncrement: field.AutoIncrement,
+			DefaultValue:  field.DefaultValue,
+		}
+		if !strings.EqualFold(dialect.ColumnType(natural), dialect.ColumnType(field)) {
+			tags = append(tags, "type:"+field.SQLType)
+		}
+
+		if field.IsPrimaryKey {
+			tags = append(tags, "primaryKey")
+		}
+
+		if field.AutoIncrement {
+			tags = append(tags, "autoIncrement")
+		} else if field.IsPrimaryKey && isIntegerType(goType) {
+			// Sem a tag o GORM trataria a chave inteira como autoincremento
+			tags = append(tags, "autoIncrement:false")
+		}
+
+		if field.IsUnique {
+			tags = append(tags, "unique")
+		}
+
+		if field.IsIndex {
+			tags = append(tags, "index")
+		}
+
+		tags = append(tags, indexTags(model, column)...)
+
+		if field.DefaultValue != "" {
+			tags = append(tags, "default:"+escapeTagValue(field.DefaultValue))
+		}
+
+		if field.Comment != "" {
+			tags = append(tags, "comment:"+escapeTagValue(field.Comment))
+		}
+
+		if goType == "time.Time" && nullable && column == "deleted_at" {
+			goType = "gorm.DeletedAt"
+		} else if nullable {
+			goType = "*" + goType
+		}
+
+		fields = append(fields, generatedField{name: name, goType: goType, tags: tags})
+	}
+
+	assignChecks(model, fields)
+
+	return fields
+}
+
+// inspectedGoType escolhe o tipo Go pelo tipo SQL da coluna.
+func inspectedGoType(dialect Dialect, field FieldInfo) string {
+	sqlType := normalizeSQLType(field.SQLType)
+	unsigned := ""
+	if strings.Contains(sqlType, "UNSIGNED") {
+		unsigned = "u"
+	}
+
+	goType := "string"
+	switch sqlTypeNamePattern.FindString(sqlType) {
+	case "BIGINT", "BIGSERIAL":
+		goType = unsigned + "int64"
+	case "INTEGER", "MEDIUMINT", "SERIAL":
+		goType = unsigned + "int"
+	case "SMALLINT", "SMALLSERIAL":
+		goType = unsigned + "int16"
+	case "TINYINT":
+		goType = unsigned + "int8"
+		if strings.HasPrefix(sqlType, "TINYINT(1)") {
+			goType = "bool"
+		}
+	case "BOOLEAN", "BIT":
+		goType = "bool"
+	case "REAL", "FLOAT":
+		goType = "float32"
+	case "DOUBLE", "NUMERIC":
+		goType = "float64"
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATE":
+		goType = "time.Time"
+	case "UUID":
+		goType = "uuid.UUID"
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY":
+		goType = "[]byte"
+	}
+
+	// No SQLite as datas dos models são TEXT; colunas *_at seguem essa convenção
+	if goType == "string" && strings.HasSuffix(columnName(field), "_at") &&
+		strings.EqualFold(dialect.ColumnType(FieldInfo{Type: "time.Time"}), dialect.ColumnType(field)) {
+		goType = "time.Time"
+	}
+
+	return goType
+}
+
+func isIntegerType(goType string) bool {
+	return strings.HasPrefix(goType, "int") || strings.HasPrefix(goType, "uint")
+}
+
+func inModelIndex(model ModelInfo, column string) bool {
+	for _, index := range model.Indexes {
+		for _, indexColumn := range index.Columns {
+			if strings.EqualFold(indexColumn, column) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// indexTags gera index:nome,opções para cada índice de ModelInfo.Indexes que
+// usa a coluna.
+func indexTags(model ModelInfo, column string) []string {
+	var tags []string
+
+	for _, index := range model.Indexes {
+		for i, indexColumn := range index.Columns {
+			if !strings.EqualFold(indexColumn, column) {
+				continue
+			}
+
+			tag := "index:" + index.Name
+			if index.Unique {
+				tag += ",unique"
+			}
+			if sort := indexSort(index, i); sort != "" {
+				tag += ",sort:" + strings.ToLower(sort)
+			}
+			if index.Where != "" {
+				tag += ",where:" + index.Where
+			}
+			if len(index.Columns) > 1 {
+				tag += ",priority:" + strconv.Itoa(i+1)
+			}
+
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// assignChecks coloca cada check em um campo citado na expressão, com o nome
+// explícito; cada campo aceita apenas uma tag check.
+func assignChecks(model ModelInfo, fields []generatedField) {
+	taken := make(map[int]bool)
+
+	for _, check := range model.Checks {
+		target := -1
+		for i, field := range model.Fields {
+			if !taken[i] && strings.Contains(check.Expression, columnName(field)) {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			for i := range model.Fields {
+				if !taken[i] {
+					target = i
+					break
+				}
+			}
+		}
+		if target < 0 {
+			continue
+		}
+
+		taken[target] = true
+		fields[target].tags = append(fields[target].tags, fmt.Sprintf("check:%s,%s", check.Name, escapeTagValue(check.Expression)))
+	}
+}
+
+// belongsToField gera a associação de uma chave estrangeira com as tags
+// foreignKey, references e constraint explícitas. O campo se chama como a
+// coluna sem o sufixo ID (UserID -> User) ou como o struct referenciado.
+func belongsToField(foreignKey ForeignKeyInfo, targetStruct string, names, targetNames map[string]string, used map[string]bool) generatedField {
+	var foreignFields, references []string
+	for _, column := range foreignKey.Columns {
+		foreignFields = append(foreignFields, fieldNameOrColumn(names, column))
+	}
+	for _, column := range foreignKey.RefColumns {
+		references = append(references, fieldNameOrColumn(targetNames, column))
+	}
+
+	name := targetStruct
+	if len(foreignFields) == 1 && len(foreignFields[0]) > 2 && strings.HasSuffix(foreignFields[0], "ID") {
+		name = strings.TrimSuffix(foreignFields[0], "ID")
+	}
+
+	var actions []string
+	if foreignKey.OnDelete != "" {
+		actions = append(actions, "OnDelete:"+foreignKey.OnDelete)
+	}
+	if foreignKey.OnUpdate != "" {
+		actions = append(actions, "OnUpdate:"+foreignKey.OnUpdate)
+	}
+
+	return generatedField{
+		name:   uniqueIdentifier(used, name),
+		goType: "*" + targetStruct,
+		tags: []string{
+			"foreignKey:" + strings.Join(foreignFields, ","),
+			"references:" + strings.Join(references, ","),
+			// O nome só é lido quando seguido de vírgula, como no GORM
+			"constraint:" + foreignKey.Name + "," + strings.Join(actions, ","),
+		},
+	}
+}
+
+func fieldNameOrColumn(names map[string]string, column string) string {
+	if name, ok := names[strings.ToLower(column)]; ok {
+		return name
+	}
+	return column
+}
+
+// goIdentifier converte um nome de tabela ou coluna em um identificador Go
+// exportado, com as siglas comuns em maiúsculas (user_id -> UserID).
+func goIdentifier(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var identifier strings.Builder
+	for _, part := range parts {
+		if commonInitialisms[strings.ToUpper(part)] {
+			identifier.WriteString(strings.ToUpper(part))
+		} else {
+			identifier.WriteString(upperFirst(part))
+		}
+	}
+
+	result := identifier.String()
+	if result == "" || !unicode.IsLetter([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+func uniqueIdentifier(used map[string]bool, name string) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// escapeTagValue protege o separador das opções da tag gorm.
+func escapeTagValue(value string) string {
+	return strings.ReplaceAll(value, ";", "\\;")
+}
+
+func modelFileName(tableName string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, tableName)
+
+	if parts := strings.Split(name, "_"); len(parts) > 1 && buildFileSuffixes[parts[len(parts)-1]] {
+		name += "_model"
+	}
+
+	return name + ".go"
+}
+
+// modelSource escreve o arquivo do model já formatado com gofmt.
+func modelSource(structName, tableName string, fields []generatedField, modulePath string) ([]byte, error) {
+	imports := map[string]bool{modulePath + "/internal/engine/patterns": true}
+	for _, field := range fields {
+		switch strings.TrimPrefix(field.goType, "*") {
+		case "time.Time":
+			imports["time"] = true
+		case "uuid.UUID":
+			imports["github.com/google/uuid"] = true
+		case "gorm.DeletedAt":
+			imports["gorm.io/gorm"] = true
+		}
+	}
+
+	var standard, external []string
+	for path := range imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") || strings.HasPrefix(path, modulePath+"/") {
+			external = append(external, path)
+		} else {
+			standard = append(standard, path)
+		}
+	}
+	sort.Strings(standard)
+	sort.Strings(external)
+
+	var source strings.Builder
+	source.WriteString("// Código gerado pelo inspectdb a partir da tabela " + tableName + ".\n\n")
+	source.WriteString("package models\n\nimport (\n")
+	for _, path := range standard {
+		source.WriteString(strconv.Quote(path) + "\n")
+	}
+	if len(standard) > 0 {
+		source.WriteString("\n")
+	}
+	for _, path := range external {
+		source.WriteString(strconv.Quote(path) + "\n")
+	}
+	source.WriteString(")\n\n")
+
+	source.WriteString(fmt.Sprintf("type %s struct {\n", structName))
+	for _, field := range fields {
+		source.WriteString(fmt.Sprintf("%s %s", field.name, field.goType))
+		if len(field.tags) > 0 {
+			tag := "gorm:" + strconv.Quote(strings.Join(field.tags, ";"))
+			if strings.Contains(tag, "`") {
+				source.WriteString(" " + strconv.Quote(tag))
+			} else {
+				source.WriteString(" `" + tag + "`")
+			}
+		}
+		source.WriteString("\n")
+	}
+	source.WriteString("}\n\n")
+
+	source.WriteString(fmt.Sprintf("func (%s) TableName() string {\nreturn %s\n}\n\n", structName, strconv.Quote(tableName)))
+	source.WriteString(fmt.Sprintf("func (m %s) Validate() error {\nreturn nil\n}\n\n", structName))
+	source.WriteString(fmt.Sprintf("func (m %s) ToDTO() *patterns.DTO {\nreturn nil\n}\n", structName))
+
+	return format.Source([]byte(source.String()))
+}
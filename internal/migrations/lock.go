@@ -0,0 +1,107 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const LockTable = "gaver_migrations_lock"
+
+// DefaultLockTimeout é o tempo que o migrate espera por outra execução antes
+// de desistir.
+const DefaultLockTimeout = time.Minute
+
+// Intervalo entre tentativas nos bancos sem espera nativa pelo bloqueio
+const lockRetryInterval = 500 * time.Millisecond
+
+// ErrLockTimeout é retornado por Dialect.AcquireLock quando o bloqueio não é
+// liberado dentro do tempo de espera.
+var ErrLockTimeout = errors.New("tempo de espera pelo bloqueio de migrações esgotado")
+
+// MigrationLock identifica quem está executando migrações no momento. No
+// SQLite a própria linha é o bloqueio; nos demais bancos o bloqueio é um
+// advisory lock e a linha serve apenas para informar quem o detém.
+type MigrationLock struct {
+	ID       int       `gorm:"column:id;primaryKey;autoIncrement:false"`
+	Holder   string    `gorm:"column:holder;not null"`
+	LockedAt time.Time `gorm:"column:locked_at;not null"`
+}
+
+func (MigrationLock) TableName() string {
+	return LockTable
+}
+
+type LockTimeoutError struct {
+	Holder   string
+	LockedAt time.Time
+	Timeout  time.Duration
+	Err      error
+}
+
+func (e *LockTimeoutError) Error() string {
+	if e.Holder == "" {
+		return fmt.Sprintf("as migrações estão sendo executadas por outro processo; aguardado %s: %v", e.Timeout, e.Err)
+	}
+
+	return fmt.Sprintf("as migrações estão sendo executadas por %s desde %s; aguardado %s: %v", e.Holder, e.LockedAt.Format("2006-01-02 15:04:05"), e.Timeout, e.Err)
+}
+
+func (e *LockTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// AcquireMigrationLock impede que duas execuções apliquem migrações ao mesmo
+// tempo, por exemplo réplicas iniciadas juntas em um deploy. Espera até
+// timeout pelo bloqueio e retorna a função que o libera.
+func AcquireMigrationLock(db *gorm.DB, dialect Dialect, timeout time.Duration) (func() error, error) {
+	if err := db.AutoMigrate(&MigrationLock{}); err != nil {
+		return nil, fmt.Errorf("erro ao criar tabela %s: %w", LockTable, err)
+	}
+
+	release, err := dialect.AcquireLock(db, lockHolder(), timeout)
+	if errors.Is(err, ErrLockTimeout) {
+		lockErr := &LockTimeoutError{Timeout: timeout, Err: err}
+
+		var current MigrationLock
+		if db.Limit(1).Find(&current).Error == nil && current.Holder != "" {
+			lockErr.Holder = current.Holder
+			lockErr.LockedAt = current.LockedAt
+		}
+
+		return nil, lockErr
+	}
+
+	return release, err
+}
+
+// lockHolder identifica esta execução na mensagem exibida a quem aguarda.
+func lockHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "host desconhecido"
+	}
+
+	return fmt.Sprintf("%s (pid %d)", hostname, os.Getpid())
+}
+
+// writeLockHolder registra quem obteve o advisory lock, substituindo o registro
+// deixado por uma execução interrompida.
+func writeLockHolder(db *gorm.DB, holder string) error {
+	if err := db.Save(&MigrationLock{ID: 1, Holder: holder, LockedAt: time.Now()}).Error; err != nil {
+		return fmt.Errorf("erro ao registrar bloqueio de migrações: %w", err)
+	}
+
+	return nil
+}
+
+func clearLockHolder(db *gorm.DB) error {
+	if err := db.Where("id = ?", 1).Delete(&MigrationLock{}).Error; err != nil {
+		return fmt.Errorf("erro ao liberar bloqueio de migrações: %w", err)
+	}
+
+	return nil
+}
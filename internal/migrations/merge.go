@@ -0,0 +1,153 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MigrationConflict agrupa arquivos com o mesmo número, normalmente criados
+// em branches diferentes a partir do mesmo migrationTag.
+type MigrationConflict struct {
+	Number int
+	Files  []MigrationFile
+}
+
+func (c MigrationConflict) String() string {
+	var names []string
+	for _, file := range c.Files {
+		names = append(names, file.FullName)
+	}
+
+	return fmt.Sprintf("%04d: %s", c.Number, strings.Join(names, ", "))
+}
+
+// RenumberedMigration é um arquivo movido por RenumberConflicts.
+type RenumberedMigration struct {
+	Previous MigrationFile
+	Current  MigrationFile
+	// Indica se o registro em gaver_migrations do banco conectado também
+	// precisa passar para o novo número
+	Applied bool
+}
+
+// FindMigrationConflicts retorna os números usados por mais de um arquivo.
+func FindMigrationConflicts(files []MigrationFile) []MigrationConflict {
+	byNumber := make(map[int][]MigrationFile)
+	for _, file := range files {
+		byNumber[file.Number] = append(byNumber[file.Number], file)
+	}
+
+	var conflicts []MigrationConflict
+	for number, conflicting := range byNumber {
+		if len(conflicting) > 1 {
+			conflicts = append(conflicts, MigrationConflict{Number: number, Files: conflicting})
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Number < conflicts[j].Number
+	})
+
+	return conflicts
+}
+
+// RenumberConflicts resolve os conflitos de numeração movendo os arquivos
+// excedentes para depois da última migração. Em cada conflito fica com o
+// número o arquivo vindo de outra branch: a migração já aplicada no banco
+// conectado é a da branch local, que ainda não chegou aos outros bancos, e por
+// isso é a renumerada. Migrações em Go e squashes nunca são renumerados. O
+// snapshot do último arquivo renumerado é refeito com o schema combinado.
+func RenumberConflicts(dialect Dialect, migrationsDir string, files []MigrationFile, applied []AppliedMigration) ([]RenumberedMigration, error) {
+	appliedNames := make(map[int]string)
+	for _, migration := range applied {
+		appliedNames[migration.Number] = migration.Name
+	}
+
+	next := 1
+	for _, file := range files {
+		if file.Number >= next {
+			next = file.Number + 1
+		}
+	}
+
+	var renumbered []RenumberedMigration
+	for _, conflict := range FindMigrationConflicts(files) {
+		keep := conflictToKeep(conflict, appliedNames)
+
+		for i, file := range conflict.Files {
+			if i == keep {
+				continue
+			}
+
+			if file.Go != nil || len(file.Replaces) > 0 {
+				return renumbered, fmt.Errorf("conflito %s: %s não pode ser renumerada; altere o número manualmente", conflict, file.FullName)
+			}
+
+			current := MigrationFile{
+				Path:     filepath.Join(migrationsDir, fmt.Sprintf("%04d_%s.sql", next, file.Name)),
+				Number:   next,
+				Name:     file.Name,
+				FullName: fmt.Sprintf("%04d_%s.sql", next, file.Name),
+			}
+
+			if err := os.Rename(file.Path, current.Path); err != nil {
+				return renumbered, fmt.Errorf("erro ao renomear %s: %w", file.FullName, err)
+			}
+
+			// O snapshot descrevia apenas o schema da branch de origem
+			if err := os.Remove(SnapshotPath(file.Path)); err != nil && !os.IsNotExist(err) {
+				return renumbered, fmt.Errorf("erro ao remover snapshot de %s: %w", file.FullName, err)
+			}
+
+			renumbered = append(renumbered, RenumberedMigration{
+				Previous: file,
+				Current:  current,
+				Applied:  appliedNames[file.Number] == file.Name,
+			})
+			next++
+		}
+	}
+
+	if len(renumbered) == 0 {
+		return nil, nil
+	}
+
+	models, err := LoadSchemaFromMigrations(dialect, migrationsDir)
+	if err != nil {
+		return renumbered, err
+	}
+
+	last := renumbered[len(renumbered)-1].Current
+	snapshot := SchemaSnapshot{
+		MigrationNumber: last.Number,
+		MigrationName:   strings.TrimSuffix(last.FullName, ".sql"),
+		Models:          models,
+	}
+	if err := WriteSnapshot(last.Path, snapshot); err != nil {
+		return renumbered, err
+	}
+
+	return renumbered, nil
+}
+
+// conflictToKeep escolhe o arquivo do conflito que mantém o número: uma
+// migração em Go ou squash, que não podem ser renomeados, ou senão o primeiro
+// arquivo não aplicado no banco conectado.
+func conflictToKeep(conflict MigrationConflict, appliedNames map[int]string) int {
+	for i, file := range conflict.Files {
+		if file.Go != nil || len(file.Replaces) > 0 {
+			return i
+		}
+	}
+
+	for i, file := range conflict.Files {
+		if appliedNames[file.Number] != file.Name {
+			return i
+		}
+	}
+
+	return 0
+}
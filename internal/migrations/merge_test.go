@@ -0,0 +1,116 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConflictToKeep(t *testing.T) {
+	local := MigrationFile{Number: 2, Name: "add_phone"}
+	remote := MigrationFile{Number: 2, Name: "orders"}
+	goMigration := MigrationFile{Number: 2, Name: "seed", Go: &GoMigration{}}
+	squash := MigrationFile{Number: 2, Name: "squashed_0003", Replaces: []ReplacedMigration{{2, "a"}, {3, "b"}}}
+
+	tests := []struct {
+		name    string
+		files   []MigrationFile
+		applied map[int]string
+		want    int
+	}{
+		{"nenhum aplicado fica o primeiro", []MigrationFile{local, remote}, nil, 0},
+		{"o aplicado é renumerado", []MigrationFile{local, remote}, map[int]string{2: "add_phone"}, 1},
+		{"o aplicado é renumerado na outra ordem", []MigrationFile{remote, local}, map[int]string{2: "add_phone"}, 0},
+		{"migração em Go mantém o número", []MigrationFile{local, goMigration}, nil, 1},
+		{"squash mantém o número mesmo aplicado", []MigrationFile{squash, remote}, map[int]string{2: "a"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflict := MigrationConflict{Number: 2, Files: tt.files}
+			if got := conflictToKeep(conflict, tt.applied); got != tt.want {
+				t.Errorf("conflictToKeep = %d (%s), esperado %d (%s)", got, tt.files[got].Name, tt.want, tt.files[tt.want].Name)
+			}
+		})
+	}
+}
+
+// Duas branches criaram a 0002 a partir da 0001. A local, já aplicada no
+// banco, vai para 0003; o snapshot dela é removido e o da nova última
+// migração descreve o schema combinado.
+func TestRenumberConflicts(t *testing.T) {
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+
+	withPhone := goldenUsers()
+	withPhone.Fields = append(withPhone.Fields, FieldInfo{Name: "Phone", Column: "phone", Type: "string", Size: 20})
+
+	writeModelMigration(t, dialect, dir, 1, "initial", nil, []ModelInfo{goldenUsers()})
+	local := writeModelMigration(t, dialect, dir, 2, "add_phone", []ModelInfo{goldenUsers()}, []ModelInfo{withPhone})
+	remote := writeModelMigration(t, dialect, dir, 2, "orders", []ModelInfo{goldenUsers()}, []ModelInfo{goldenUsers(), goldenOrders()})
+
+	snapshots := []struct {
+		file   MigrationFile
+		models []ModelInfo
+	}{
+		{local, []ModelInfo{withPhone}},
+		{remote, []ModelInfo{goldenUsers(), goldenOrders()}},
+	}
+	for _, snapshot := range snapshots {
+		if err := WriteSnapshot(snapshot.file.Path, SchemaSnapshot{MigrationNumber: 2, MigrationName: snapshot.file.Name, Models: snapshot.models}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied := []AppliedMigration{{Number: 1, Name: "initial"}, {Number: 2, Name: "add_phone"}}
+	renumbered, err := RenumberConflicts(dialect, dir, files, applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(renumbered) != 1 {
+		t.Fatalf("renumeradas = %+v, esperado apenas add_phone", renumbered)
+	}
+	moved := renumbered[0]
+	if moved.Previous.FullName != "0002_add_phone.sql" || moved.Current.FullName != "0003_add_phone.sql" || !moved.Applied {
+		t.Errorf("renumerada = %s -> %s (aplicada: %v), esperado 0002_add_phone.sql -> 0003_add_phone.sql aplicada", moved.Previous.FullName, moved.Current.FullName, moved.Applied)
+	}
+
+	if _, err := os.Stat(local.Path); !os.IsNotExist(err) {
+		t.Error("0002_add_phone.sql deveria ter sido renomeado")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "0003_add_phone.sql")); err != nil {
+		t.Errorf("0003_add_phone.sql não encontrado: %v", err)
+	}
+
+	if _, err := os.Stat(SnapshotPath(local.Path)); !os.IsNotExist(err) {
+		t.Error("o snapshot de 0002_add_phone deveria ter sido removido")
+	}
+	if _, err := os.Stat(SnapshotPath(remote.Path)); err != nil {
+		t.Errorf("o snapshot de 0002_orders deveria continuar: %v", err)
+	}
+
+	snapshot, err := ReadSnapshot(SnapshotPath(moved.Current.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.MigrationNumber != 3 || snapshot.MigrationName != "0003_add_phone" {
+		t.Errorf("snapshot = %d %s, esperado 3 0003_add_phone", snapshot.MigrationNumber, snapshot.MigrationName)
+	}
+	if diff := DiffModels(snapshot.Models, []ModelInfo{withPhone, goldenOrders()}, dialect); !diff.IsEmpty() {
+		t.Errorf("snapshot difere do schema combinado: %v", DescribeDiff(diff))
+	}
+
+	files, err = ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conflicts := FindMigrationConflicts(files); len(conflicts) > 0 {
+		t.Errorf("ainda há conflitos: %v", conflicts)
+	}
+}
@@ -0,0 +1,513 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MySQLDialect struct{}
+
+func init() {
+	RegisterDialect(MySQLDialect{})
+}
+
+func (MySQLDialect) Name() string {
+	return "mysql"
+}
+
+func (MySQLDialect) QuoteIdentifier(name string) string {
+	return fmt.Sprintf("`%s`", strings.ReplaceAll(name, "`", "``"))
+}
+
+// SupportsTransactionalDDL é falso pois no MySQL comandos DDL fazem commit
+// implícito e não são desfeitos pela transação da migração.
+func (MySQLDialect) SupportsTransactionalDDL() bool {
+	return false
+}
+
+func (MySQLDialect) SupportsColumnComments() bool {
+	return true
+}
+
+// O MySQL não tem índices parciais; a tag where é ignorada, como no GORM
+func (MySQLDialect) SupportsPartialIndexes() bool {
+	return false
+}
+
+// Sem o modo NO_BACKSLASH_ESCAPES, 'it\'s' é uma string válida no MySQL
+func (MySQLDialect) SupportsBackslashEscapes() bool {
+	return true
+}
+
+func (d MySQLDialect) CreateTable(model ModelInfo) string {
+	return createTableSQL(d, model, d.field, createTableOptions{suffix: " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"})
+}
+
+func (d MySQLDialect) DropTable(model ModelInfo) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", d.QuoteIdentifier(model.TableName))
+}
+
+// O MySQL não aceita IF NOT EXISTS em CREATE INDEX
+func (d MySQLDialect) CreateIndex(index IndexInfo) string {
+	return createIndexSQL(d, index, false)
+}
+
+func (d MySQLDialect) DropIndex(index IndexInfo) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s;", d.QuoteIdentifier(index.Name), d.QuoteIdentifier(index.Table))
+}
+
+func (d MySQLDialect) ForeignKey(foreignKey ForeignKeyInfo) string {
+	return foreignKeySQL(d, foreignKey)
+}
+
+// AlterTable redefine por completo as colunas alteradas com MODIFY COLUMN.
+func (d MySQLDialect) AlterTable(table TableDiff) string {
+	var sql strings.Builder
+	tableName := d.QuoteIdentifier(table.Current.TableName)
+
+	// Removidas antes dos índices: o MySQL não remove o índice usado por uma
+	// chave estrangeira
+	for _, foreignKey := range table.DroppedForeignKeys {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;\n", tableName, d.QuoteIdentifier(foreignKey.Name)))
+	}
+
+	for _, index := range table.DroppedIndexes {
+		sql.WriteString(d.DropIndex(index) + "\n")
+	}
+
+	// Removidas antes das colunas: o MySQL não remove uma coluna usada em
+	// CHECK. DROP CHECK não existe no MariaDB; DROP CONSTRAINT é aceito por ele
+	// e pelo MySQL a partir do 8.0.19
+	for _, check := range table.DroppedChecks {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;\n", tableName, d.QuoteIdentifier(check.Name)))
+	}
+
+	if primaryKeyChanged(table) && len(primaryKeyColumns(table.Previous)) > 0 {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;\n", tableName))
+	}
+
+	for _, field := range table.DroppedColumns {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", tableName, d.QuoteIdentifier(columnName(field))))
+	}
+
+	for _, field := range table.AddedColumns {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", tableName, d.field(field)))
+	}
+
+	for _, change := range table.ChangedColumns {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;\n", tableName, d.field(change.Current)))
+	}
+
+	if primaryKeyChanged(table) {
+		if columns := primaryKeyColumns(table.Current); len(columns) > 0 {
+			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);\n", tableName, quoteColumns(d, columns)))
+		}
+	}
+
+	for _, index := range table.CreatedIndexes {
+		sql.WriteString(d.CreateIndex(index) + "\n")
+	}
+
+	for _, check := range table.CreatedChecks {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD %s;\n", tableName, checkSQL(d, check)))
+	}
+
+	for _, foreignKey := range table.CreatedForeignKeys {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD %s;\n", tableName, d.ForeignKey(foreignKey)))
+	}
+
+	return sql.String()
+}
+
+func (d MySQLDialect) field(field FieldInfo) string {
+	var parts []string
+	parts = append(parts, d.QuoteIdentifier(columnName(field)))
+	parts = append(parts, d.ColumnType(field))
+
+	if field.IsNotNull || field.IsPrimaryKey {
+		parts = append(parts, "NOT NULL")
+	} else {
+		parts = append(parts, "NULL")
+	}
+
+	if field.AutoIncrement {
+		parts = append(parts, "AUTO_INCREMENT")
+	}
+
+	if field.DefaultValue != "" {
+		parts = append(parts, fmt.Sprintf("DEFAULT %s", field.DefaultValue))
+	}
+
+	if field.Comment != "" {
+		parts = append(parts, fmt.Sprintf("COMMENT %s", quoteString(field.Comment)))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ColumnType segue os tipos usados pelo GORM no MySQL. Strings que fazem
+// parte de chave ou índice precisam de tamanho fixo, pois colunas TEXT não
+// podem ser indexadas sem prefixo.
+func (MySQLDialect) ColumnType(field FieldInfo) string {
+	if field.SQLType != "" {
+		// patterns.DefaultModel declara type:uuid, que não existe no MySQL
+		if strings.EqualFold(field.SQLType, "uuid") {
+			return "CHAR(36)"
+		}
+		return field.SQLType
+	}
+
+	switch field.Type {
+	case "string":
+		return mysqlStringType(field)
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return mysqlIntegerType(field)
+	case "float32", "float64":
+		if field.Precision > 0 {
+			return numericType("DECIMAL", field)
+		}
+		if field.Type == "float32" {
+			return "FLOAT"
+		}
+		return "DOUBLE"
+	case "bool", "boolean":
+		return "TINYINT(1)"
+	case "time.Time", "gorm.DeletedAt":
+		if field.Precision > 0 {
+			return fmt.Sprintf("DATETIME(%d)", field.Precision)
+		}
+		return "DATETIME(6)"
+	case "uuid.UUID":
+		return "CHAR(36)"
+	case "[]byte":
+		return "LONGBLOB"
+	case "datatypes.JSON", "datatypes.JSONType", "datatypes.JSONSlice", "datatypes.JSONMap", "json.RawMessage":
+		return "JSON"
+	default:
+		if strings.HasPrefix(field.Type, "[]") || strings.HasPrefix(field.Type, "map[") {
+			return "JSON"
+		}
+		return "LONGTEXT"
+	}
+}
+
+// mysqlStringType segue o GORM: a tag size define VARCHAR, MEDIUMTEXT ou
+// LONGTEXT conforme o tamanho.
+func mysqlStringType(field FieldInfo) string {
+	size := field.Size
+	if size == 0 {
+		if field.IsPrimaryKey || field.IsIndex || field.IsUnique || field.InIndex {
+			return "VARCHAR(191)"
+		}
+		if field.DefaultValue != "" {
+			return "VARCHAR(255)"
+		}
+		return "LONGTEXT"
+	}
+
+	switch {
+	case size >= 1<<24:
+		return "LONGTEXT"
+	case size >= 1<<16:
+		return "MEDIUMTEXT"
+	default:
+		return fmt.Sprintf("VARCHAR(%d)", size)
+	}
+}
+
+func mysqlIntegerType(field FieldInfo) string {
+	size := field.Size
+	if size == 0 {
+		switch field.Type {
+		case "int8", "uint8":
+			size = 8
+		case "int16", "uint16":
+			size = 16
+		case "int", "int32", "uint32":
+			size = 32
+		default:
+			size = 64
+		}
+	}
+
+	var sqlType string
+	switch {
+	case size <= 8:
+		sqlType = "TINYINT"
+	case size <= 16:
+		sqlType = "SMALLINT"
+	case size <= 24:
+		sqlType = "MEDIUMINT"
+	case size <= 32:
+		sqlType = "INT"
+	default:
+		sqlType = "BIGINT"
+	}
+
+	if strings.HasPrefix(field.Type, "uint") {
+		sqlType += " UNSIGNED"
+	}
+
+	return sqlType
+}
+
+var (
+	integerDisplayWidthPattern = regexp.MustCompile(`(?i)^(TINYINT|SMALLINT|MEDIUMINT|INT|INTEGER|BIGINT)\(\d+\)`)
+	numericDefaultPattern      = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+)
+
+// InspectSchema lê as tabelas do banco atual pelo information_schema. As
+// checks não são lidas, pois CHECK_CONSTRAINTS só existe a partir do MySQL
+// 8.0.16.
+func (d MySQLDialect) InspectSchema(db *gorm.DB) ([]ModelInfo, error) {
+	var tables []string
+	if err := db.Raw("SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME").Scan(&tables).Error; err != nil {
+		return nil, fmt.Errorf("erro ao listar tabelas: %w", err)
+	}
+
+	var models []ModelInfo
+	for _, tableName := range tables {
+		if isInternalTable(tableName) {
+			continue
+		}
+
+		model, err := d.inspectTable(db, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler tabela %s: %w", tableName, err)
+		}
+
+		models = append(models, model)
+	}
+
+	return inspectedModels(models), nil
+}
+
+func (d MySQLDialect) inspectTable(db *gorm.DB, tableName string) (ModelInfo, error) {
+	model := ModelInfo{Name: tableName, TableName: tableName}
+
+	var columns []struct {
+		Name         string
+		Type         string
+		Nullable     string
+		ColumnKey    string
+		Extra        string
+		DefaultValue *string
+		Comment      string
+	}
+	err := db.Raw(`SELECT COLUMN_NAME AS name, COLUMN_TYPE AS type, IS_NULLABLE AS nullable, COLUMN_KEY AS column_key,
+	EXTRA AS extra, COLUMN_DEFAULT AS default_value, COLUMN_COMMENT AS comment
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`, tableName).Scan(&columns).Error
+	if err != nil {
+		return model, err
+	}
+
+	for _, column := range columns {
+		sqlType := strings.ToUpper(column.Type)
+		// A largura de exibição dos inteiros (INT(11)) é ignorada pelo MySQL;
+		// TINYINT(1) é mantido por ser o tipo usado para booleanos
+		if !strings.HasPrefix(sqlType, "TINYINT(1)") {
+			sqlType = integerDisplayWidthPattern.ReplaceAllString(sqlType, "$1")
+		}
+
+		field := FieldInfo{
+			Name:          column.Name,
+			Column:        column.Name,
+			SQLType:       sqlType,
+			IsPrimaryKey:  column.ColumnKey == "PRI",
+			IsNotNull:     column.Nullable == "NO",
+			AutoIncrement: strings.Contains(strings.ToLower(column.Extra), "auto_increment"),
+			Comment:       column.Comment,
+		}
+
+		if column.DefaultValue != nil && !strings.EqualFold(*column.DefaultValue, "NULL") {
+			field.DefaultValue = mysqlInspectedDefault(*column.DefaultValue, column.Extra)
+		}
+
+		model.Fields = append(model.Fields, field)
+	}
+
+	foreignKeys, err := d.inspectForeignKeys(db, tableName)
+	if err != nil {
+		return model, err
+	}
+	model.ForeignKeys = foreignKeys
+
+	var statistics []struct {
+		Name      string
+		NonUnique bool
+		Column    *string
+		Collation *string
+	}
+	err = db.Raw(`SELECT INDEX_NAME AS name, NON_UNIQUE AS non_unique, COLUMN_NAME AS `+"`column`"+`, COLLATION AS collation
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME <> 'PRIMARY'
+ORDER BY INDEX_NAME, SEQ_IN_INDEX`, tableName).Scan(&statistics).Error
+	if err != nil {
+		return model, err
+	}
+
+	// O MySQL cria um índice com o nome da chave estrangeira quando não há
+	// outro que a atenda
+	foreignKeyNames := make(map[string]bool)
+	for _, foreignKey := range foreignKeys {
+		foreignKeyNames[strings.ToLower(foreignKey.Name)] = true
+	}
+
+	var indexes []IndexInfo
+	positions := make(map[string]int)
+	expressions := make(map[string]bool)
+	for _, row := range statistics {
+		if foreignKeyNames[strings.ToLower(row.Name)] {
+			continue
+		}
+
+		if row.Column == nil {
+			expressions[row.Name] = true
+			continue
+		}
+
+		position, ok := positions[row.Name]
+		if !ok {
+			indexes = append(indexes, IndexInfo{Name: row.Name, Table: tableName, Unique: !row.NonUnique})
+			position = len(indexes) - 1
+			positions[row.Name] = position
+		}
+
+		order := ""
+		if row.Collation != nil && *row.Collation == "D" {
+			order = "DESC"
+		}
+
+		indexes[position].Columns = append(indexes[position].Columns, *row.Column)
+		indexes[position].Sorts = append(indexes[position].Sorts, order)
+	}
+
+	for _, index := range indexes {
+		// Índices sobre expressões não têm representação nos models
+		if expressions[index.Name] {
+			continue
+		}
+
+		if strings.Join(index.Sorts, "") == "" {
+			index.Sorts = nil
+		}
+
+		model.Indexes = append(model.Indexes, index)
+	}
+
+	return model, nil
+}
+
+func (d MySQLDialect) inspectForeignKeys(db *gorm.DB, tableName string) ([]ForeignKeyInfo, error) {
+	var rows []struct {
+		Name      string
+		Column    string
+		RefTable  string
+		RefColumn string
+		OnDelete  string
+		OnUpdate  string
+	}
+	err := db.Raw(`SELECT k.CONSTRAINT_NAME AS name, k.COLUMN_NAME AS `+"`column`"+`, k.REFERENCED_TABLE_NAME AS ref_table,
+	k.REFERENCED_COLUMN_NAME AS ref_column, r.DELETE_RULE AS on_delete, r.UPDATE_RULE AS on_update
+FROM information_schema.KEY_COLUMN_USAGE k
+JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+	ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME
+WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION`, tableName).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var foreignKeys []ForeignKeyInfo
+	positions := make(map[string]int)
+	for _, row := range rows {
+		position, ok := positions[row.Name]
+		if !ok {
+			foreignKeys = append(foreignKeys, ForeignKeyInfo{
+				Name:     row.Name,
+				RefTable: row.RefTable,
+				OnDelete: inspectedAction(row.OnDelete),
+				OnUpdate: inspectedAction(row.OnUpdate),
+			})
+			position = len(foreignKeys) - 1
+			positions[row.Name] = position
+		}
+
+		foreignKeys[position].Columns = append(foreignKeys[position].Columns, row.Column)
+		foreignKeys[position].RefColumns = append(foreignKeys[position].RefColumns, row.RefColumn)
+	}
+
+	return foreignKeys, nil
+}
+
+// mysqlInspectedDefault devolve o default como seria escrito no DDL. O
+// information_schema traz literais sem aspas; expressões são marcadas com
+// DEFAULT_GENERATED em EXTRA.
+func mysqlInspectedDefault(value, extra string) string {
+	if strings.Contains(strings.ToUpper(extra), "DEFAULT_GENERATED") || numericDefaultPattern.MatchString(value) ||
+		strings.HasPrefix(strings.ToUpper(value), "CURRENT_TIMESTAMP") {
+		return value
+	}
+	return quoteString(value)
+}
+
+// Nome do GET_LOCK das migrações. Os nomes valem para o servidor inteiro, por
+// isso o nome do banco é acrescentado (limitado aos 64 caracteres aceitos).
+const mysqlLockName = "LEFT(CONCAT('gaver_migrations.', DATABASE()), 64)"
+
+// AcquireLock obtém um GET_LOCK em uma conexão reservada, mantida aberta até
+// a liberação. Se o processo morrer, o MySQL libera o bloqueio junto com a
+// conexão.
+func (MySQLDialect) AcquireLock(db *gorm.DB, holder string, timeout time.Duration) (func() error, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter conexão para o bloqueio de migrações: %w", err)
+	}
+
+	// GET_LOCK retorna 1 ao obter o bloqueio, 0 ao esgotar o tempo e NULL em erro
+	var locked sql.NullInt64
+	seconds := int(math.Ceil(timeout.Seconds()))
+	if err := conn.QueryRowContext(context.Background(), "SELECT GET_LOCK("+mysqlLockName+", ?)", seconds).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("erro ao obter bloqueio de migrações: %w", err)
+	}
+
+	if !locked.Valid || locked.Int64 != 1 {
+		conn.Close()
+		if locked.Valid {
+			return nil, ErrLockTimeout
+		}
+		return nil, fmt.Errorf("erro ao obter bloqueio de migrações: GET_LOCK retornou NULL")
+	}
+
+	release := func() error {
+		defer conn.Close()
+
+		clearErr := clearLockHolder(db)
+		if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK("+mysqlLockName+")"); err != nil {
+			return fmt.Errorf("erro ao liberar bloqueio de migrações: %w", err)
+		}
+		return clearErr
+	}
+
+	if err := writeLockHolder(db, holder); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
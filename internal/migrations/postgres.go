@@ -0,0 +1,538 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostgresDialect struct{}
+
+func init() {
+	RegisterDialect(PostgresDialect{})
+}
+
+func (PostgresDialect) Name() string {
+	return "postgres"
+}
+
+func (PostgresDialect) QuoteIdentifier(name string) string {
+	return fmt.Sprintf("\"%s\"", strings.ReplaceAll(name, "\"", "\"\""))
+}
+
+func (PostgresDialect) SupportsTransactionalDDL() bool {
+	return true
+}
+
+func (PostgresDialect) SupportsColumnComments() bool {
+	return true
+}
+
+func (PostgresDialect) SupportsPartialIndexes() bool {
+	return true
+}
+
+func (PostgresDialect) SupportsBackslashEscapes() bool {
+	return false
+}
+
+// CreateTable inclui os comentários de coluna, que no PostgreSQL são
+// definidos com COMMENT ON após a criação da tabela.
+func (d PostgresDialect) CreateTable(model ModelInfo) string {
+	sql := createTableSQL(d, model, d.field, createTableOptions{})
+
+	for _, field := range model.Fields {
+		if field.Comment != "" {
+			sql += d.comment(model.TableName, field) + "\n"
+		}
+	}
+
+	return sql
+}
+
+func (d PostgresDialect) DropTable(model ModelInfo) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", d.QuoteIdentifier(model.TableName))
+}
+
+func (d PostgresDialect) CreateIndex(index IndexInfo) string {
+	return createIndexSQL(d, index, true)
+}
+
+func (d PostgresDialect) DropIndex(index IndexInfo) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s;", d.QuoteIdentifier(index.Name))
+}
+
+func (d PostgresDialect) ForeignKey(foreignKey ForeignKeyInfo) string {
+	return foreignKeySQL(d, foreignKey)
+}
+
+// AlterTable altera colunas no lugar com ALTER COLUMN. Ao contrário do
+// SQLite, o PostgreSQL não precisa recriar a tabela.
+func (d PostgresDialect) AlterTable(table TableDiff) string {
+	var sql strings.Builder
+	tableName := d.QuoteIdentifier(table.Current.TableName)
+
+	for _, index := range table.DroppedIndexes {
+		sql.WriteString(d.DropIndex(index) + "\n")
+	}
+
+	for _, foreignKey := range table.DroppedForeignKeys {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n", tableName, d.QuoteIdentifier(foreignKey.Name)))
+	}
+
+	for _, check := range table.DroppedChecks {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n", tableName, d.QuoteIdentifier(check.Name)))
+	}
+
+	if primaryKeyChanged(table) && len(primaryKeyColumns(table.Previous)) > 0 {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n", tableName, d.QuoteIdentifier(table.Current.TableName+"_pkey")))
+	}
+
+	for _, field := range table.DroppedColumns {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", tableName, d.QuoteIdentifier(columnName(field))))
+	}
+
+	for _, field := range table.AddedColumns {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", tableName, d.addedField(field)))
+
+		// O default provisório só serve para preencher as linhas existentes
+		if field.IsNotNull && !field.IsPrimaryKey && !field.AutoIncrement && field.DefaultValue == "" {
+			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;\n", tableName, d.QuoteIdentifier(columnName(field))))
+		}
+
+		if field.Comment != "" {
+			sql.WriteString(d.comment(table.Current.TableName, field) + "\n")
+		}
+	}
+
+	for _, change := range table.ChangedColumns {
+		sql.WriteString(d.alterColumn(table.Current.TableName, change))
+	}
+
+	if primaryKeyChanged(table) {
+		if columns := primaryKeyColumns(table.Current); len(columns) > 0 {
+			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);\n", tableName, quoteColumns(d, columns)))
+		}
+	}
+
+	for _, index := range table.CreatedIndexes {
+		sql.WriteString(d.CreateIndex(index) + "\n")
+	}
+
+	for _, check := range table.CreatedChecks {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD %s;\n", tableName, checkSQL(d, check)))
+	}
+
+	for _, foreignKey := range table.CreatedForeignKeys {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD %s;\n", tableName, d.ForeignKey(foreignKey)))
+	}
+
+	return sql.String()
+}
+
+func (d PostgresDialect) comment(tableName string, field FieldInfo) string {
+	comment := "NULL"
+	if field.Comment != "" {
+		comment = quoteString(field.Comment)
+	}
+
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;", d.QuoteIdentifier(tableName), d.QuoteIdentifier(columnName(field)), comment)
+}
+
+func (d PostgresDialect) alterColumn(tableName string, change ColumnChange) string {
+	var sql strings.Builder
+	column := d.QuoteIdentifier(columnName(change.Current))
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", d.QuoteIdentifier(tableName), column)
+
+	// SERIAL não é um tipo de verdade e não pode ser usado em TYPE; o
+	// autoIncrement é alterado criando ou removendo a sequência da coluna
+	previousType := postgresBaseType(d.ColumnType(change.Previous))
+	currentType := postgresBaseType(d.ColumnType(change.Current))
+	if !strings.EqualFold(previousType, currentType) {
+		sql.WriteString(fmt.Sprintf("%s TYPE %s USING %s::%s;\n", alter, currentType, column, currentType))
+	}
+
+	if change.Previous.AutoIncrement != change.Current.AutoIncrement {
+		sql.WriteString(d.alterSequence(tableName, columnName(change.Current), currentType, change.Current.AutoIncrement))
+	}
+
+	previousNotNull := change.Previous.IsNotNull || change.Previous.IsPrimaryKey
+	currentNotNull := change.Current.IsNotNull || change.Current.IsPrimaryKey
+	if previousNotNull != currentNotNull {
+		if currentNotNull {
+			sql.WriteString(alter + " SET NOT NULL;\n")
+		} else {
+			sql.WriteString(alter + " DROP NOT NULL;\n")
+		}
+	}
+
+	if strings.TrimSpace(change.Previous.DefaultValue) != strings.TrimSpace(change.Current.DefaultValue) {
+		if change.Current.DefaultValue != "" {
+			sql.WriteString(fmt.Sprintf("%s SET DEFAULT %s;\n", alter, change.Current.DefaultValue))
+		} else {
+			sql.WriteString(alter + " DROP DEFAULT;\n")
+		}
+	}
+
+	if change.Previous.Comment != change.Current.Comment {
+		sql.WriteString(d.comment(tableName, change.Current) + "\n")
+	}
+
+	return sql.String()
+}
+
+// alterSequence cria a sequência <tabela>_<coluna>_seq, como faria o SERIAL,
+// iniciando após o maior valor existente, ou a remove junto com o default.
+func (d PostgresDialect) alterSequence(tableName, column, columnType string, autoIncrement bool) string {
+	table := d.QuoteIdentifier(tableName)
+	quotedColumn := d.QuoteIdentifier(column)
+	sequence := d.QuoteIdentifier(tableName + "_" + column + "_seq")
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", table, quotedColumn)
+
+	if !autoIncrement {
+		return fmt.Sprintf("%s DROP DEFAULT;\nDROP SEQUENCE IF EXISTS %s;\n", alter, sequence)
+	}
+
+	var sql strings.Builder
+	sql.WriteString(fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s AS %s OWNED BY %s.%s;\n", sequence, columnType, table, quotedColumn))
+	sql.WriteString(fmt.Sprintf("SELECT setval(%s, COALESCE(MAX(%s), 0) + 1, false) FROM %s;\n", quoteString(sequence), quotedColumn, table))
+	sql.WriteString(fmt.Sprintf("%s SET DEFAULT nextval(%s);\n", alter, quoteString(sequence)))
+	return sql.String()
+}
+
+// postgresBaseType troca os tipos SERIAL pelo inteiro correspondente.
+func postgresBaseType(sqlType string) string {
+	for base, serial := range postgresSerialTypes {
+		if strings.EqualFold(sqlType, serial) {
+			return base
+		}
+	}
+
+	return sqlType
+}
+
+func (d PostgresDialect) field(field FieldInfo) string {
+	var parts []string
+	parts = append(parts, d.QuoteIdentifier(columnName(field)))
+	parts = append(parts, d.ColumnType(field))
+
+	if field.IsNotNull || field.IsPrimaryKey {
+		parts = append(parts, "NOT NULL")
+	}
+
+	if field.DefaultValue != "" {
+		parts = append(parts, fmt.Sprintf("DEFAULT %s", field.DefaultValue))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// addedField preenche as linhas existentes com o valor zero do tipo quando a
+// nova coluna é NOT NULL e não tem default.
+func (d PostgresDialect) addedField(field FieldInfo) string {
+	if field.IsNotNull && !field.IsPrimaryKey && !field.AutoIncrement && field.DefaultValue == "" {
+		field.DefaultValue = postgresZeroValue(d.ColumnType(field))
+	}
+
+	return d.field(field)
+}
+
+func postgresZeroValue(sqlType string) string {
+	// Remove o tamanho ou a precisão, ex.: NUMERIC(10,2) -> NUMERIC
+	baseType := strings.ToUpper(sqlType)
+	if i := strings.Index(baseType, "("); i >= 0 {
+		baseType = strings.TrimSpace(baseType[:i])
+	}
+
+	switch baseType {
+	case "SMALLINT", "INTEGER", "BIGINT", "REAL", "DOUBLE PRECISION", "NUMERIC":
+		return "0"
+	case "BOOLEAN":
+		return "FALSE"
+	case "TIMESTAMPTZ":
+		return "'epoch'"
+	case "UUID":
+		return "'00000000-0000-0000-0000-000000000000'"
+	case "JSONB":
+		return "'null'"
+	case "BYTEA":
+		return "''::bytea"
+	default:
+		return "''"
+	}
+}
+
+func (PostgresDialect) ColumnType(field FieldInfo) string {
+	if field.SQLType != "" {
+		return field.SQLType
+	}
+
+	switch field.Type {
+	case "string":
+		if field.Size > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", field.Size)
+		}
+		return "TEXT"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return postgresIntegerType(field)
+	case "float32", "float64":
+		if field.Precision > 0 {
+			return numericType("NUMERIC", field)
+		}
+		if field.Type == "float32" {
+			return "REAL"
+		}
+		return "DOUBLE PRECISION"
+	case "bool", "boolean":
+		return "BOOLEAN"
+	case "time.Time", "gorm.DeletedAt":
+		if field.Precision > 0 {
+			return fmt.Sprintf("TIMESTAMPTZ(%d)", field.Precision)
+		}
+		return "TIMESTAMPTZ"
+	case "uuid.UUID":
+		return "UUID"
+	case "[]byte":
+		return "BYTEA"
+	case "datatypes.JSON", "datatypes.JSONType", "datatypes.JSONSlice", "datatypes.JSONMap", "json.RawMessage":
+		return "JSONB"
+	default:
+		if strings.HasPrefix(field.Type, "[]") || strings.HasPrefix(field.Type, "map[") {
+			return "JSONB"
+		}
+		return "TEXT"
+	}
+}
+
+// postgresIntegerType escolhe o tamanho pelo tipo Go ou pela tag size e usa
+// os tipos SERIAL quando a coluna tem autoIncrement.
+func postgresIntegerType(field FieldInfo) string {
+	size := field.Size
+	if size == 0 {
+		switch field.Type {
+		case "int8", "int16", "uint8":
+			size = 16
+		case "int", "int32", "uint16":
+			size = 32
+		default:
+			size = 64
+		}
+	}
+
+	switch {
+	case size <= 16 && field.AutoIncrement:
+		return "SMALLSERIAL"
+	case size <= 16:
+		return "SMALLINT"
+	case size <= 32 && field.AutoIncrement:
+		return "SERIAL"
+	case size <= 32:
+		return "INTEGER"
+	case field.AutoIncrement:
+		return "BIGSERIAL"
+	default:
+		return "BIGINT"
+	}
+}
+
+var (
+	indexMethodPattern = regexp.MustCompile(`(?i)\s+USING\s+\w+\s*\(`)
+	defaultCastPattern = regexp.MustCompile(`^('(?:[^']|'')*')::[\w ]+$`)
+)
+
+var postgresSerialTypes = map[string]string{
+	"SMALLINT": "SMALLSERIAL",
+	"INTEGER":  "SERIAL",
+	"BIGINT":   "BIGSERIAL",
+}
+
+// InspectSchema lê as tabelas do schema atual pelo pg_catalog. Os índices e as
+// constraints são obtidos já como SQL, com pg_get_indexdef e
+// pg_get_constraintdef, e interpretados como os comandos das migrações.
+func (d PostgresDialect) InspectSchema(db *gorm.DB) ([]ModelInfo, error) {
+	var tables []string
+	if err := db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name").Scan(&tables).Error; err != nil {
+		return nil, fmt.Errorf("erro ao listar tabelas: %w", err)
+	}
+
+	var models []ModelInfo
+	for _, tableName := range tables {
+		if isInternalTable(tableName) {
+			continue
+		}
+
+		model, err := d.inspectTable(db, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler tabela %s: %w", tableName, err)
+		}
+
+		models = append(models, model)
+	}
+
+	return inspectedModels(models), nil
+}
+
+func (d PostgresDialect) inspectTable(db *gorm.DB, tableName string) (ModelInfo, error) {
+	model := ModelInfo{Name: tableName, TableName: tableName}
+
+	var columns []struct {
+		Name         string
+		Type         string
+		NotNull      bool
+		DefaultValue *string
+		Identity     string
+		PrimaryKey   bool
+		Comment      *string
+	}
+	err := db.Raw(`SELECT a.attname AS name,
+	format_type(a.atttypid, a.atttypmod) AS type,
+	a.attnotnull AS not_null,
+	pg_get_expr(ad.adbin, ad.adrelid) AS default_value,
+	a.attidentity::text AS identity,
+	EXISTS (SELECT 1 FROM pg_index i WHERE i.indrelid = c.oid AND i.indisprimary AND a.attnum = ANY(i.indkey)) AS primary_key,
+	col_description(c.oid, a.attnum) AS comment
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+WHERE n.nspname = current_schema() AND c.relname = ? AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`, tableName).Scan(&columns).Error
+	if err != nil {
+		return model, err
+	}
+
+	for _, column := range columns {
+		field := FieldInfo{
+			Name:          column.Name,
+			Column:        column.Name,
+			SQLType:       normalizeSQLType(column.Type),
+			IsPrimaryKey:  column.PrimaryKey,
+			IsNotNull:     column.NotNull,
+			AutoIncrement: column.Identity != "",
+		}
+
+		if column.DefaultValue != nil {
+			// SERIAL é um default nextval() da sequência criada para a coluna
+			if strings.HasPrefix(*column.DefaultValue, "nextval(") {
+				field.AutoIncrement = true
+			} else {
+				field.DefaultValue = defaultCastPattern.ReplaceAllString(*column.DefaultValue, "$1")
+			}
+		}
+
+		if column.Comment != nil {
+			field.Comment = *column.Comment
+		}
+
+		// Mantém o tipo que recria a sequência, como nas migrações geradas
+		if serial, ok := postgresSerialTypes[field.SQLType]; ok && field.AutoIncrement {
+			field.SQLType = serial
+		}
+
+		model.Fields = append(model.Fields, field)
+	}
+
+	var indexes []string
+	err = db.Raw(`SELECT pg_get_indexdef(i.indexrelid)
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relname = ? AND NOT i.indisprimary
+ORDER BY i.indexrelid`, tableName).Scan(&indexes).Error
+	if err != nil {
+		return model, err
+	}
+
+	for _, definition := range indexes {
+		index, ok := parseCreateIndex(indexMethodPattern.ReplaceAllString(definition, " ("))
+		if !ok {
+			continue
+		}
+
+		index.Table = tableName
+		index.Where = strings.TrimSpace(index.Where)
+		if strings.HasPrefix(index.Where, "(") && strings.HasSuffix(index.Where, ")") && len(splitTopLevel(index.Where, ' ')) == 1 {
+			index.Where = index.Where[1 : len(index.Where)-1]
+		}
+
+		model.Indexes = append(model.Indexes, index)
+	}
+
+	var constraints []struct {
+		Name       string
+		Definition string
+	}
+	err = db.Raw(`SELECT con.conname AS name, pg_get_constraintdef(con.oid) AS definition
+FROM pg_constraint con
+JOIN pg_class c ON c.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relname = ? AND con.contype IN ('f', 'c')
+ORDER BY con.conname`, tableName).Scan(&constraints).Error
+	if err != nil {
+		return model, err
+	}
+
+	for _, constraint := range constraints {
+		definition := fmt.Sprintf("CONSTRAINT %s %s", d.QuoteIdentifier(constraint.Name), constraint.Definition)
+
+		if foreignKey, ok := parseForeignKeyDefinition(definition); ok {
+			foreignKey.OnDelete = inspectedAction(foreignKey.OnDelete)
+			foreignKey.OnUpdate = inspectedAction(foreignKey.OnUpdate)
+			model.ForeignKeys = append(model.ForeignKeys, foreignKey)
+		} else if check, ok := parseCheckDefinition(definition); ok {
+			model.Checks = append(model.Checks, check)
+		}
+	}
+
+	return model, nil
+}
+
+// Chave do pg_advisory_lock das migrações ("gaver" em ASCII)
+const postgresLockKey int64 = 0x6761766572
+
+// AcquireLock obtém um pg_advisory_lock em uma conexão reservada, mantida
+// aberta até a liberação. Se o processo morrer, o PostgreSQL libera o
+// bloqueio junto com a conexão.
+func (PostgresDialect) AcquireLock(db *gorm.DB, holder string, timeout time.Duration) (func() error, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter conexão para o bloqueio de migrações: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresLockKey); err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ErrLockTimeout
+		}
+		return nil, fmt.Errorf("erro ao obter bloqueio de migrações: %w", err)
+	}
+
+	release := func() error {
+		defer conn.Close()
+
+		clearErr := clearLockHolder(db)
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", postgresLockKey); err != nil {
+			return fmt.Errorf("erro ao liberar bloqueio de migrações: %w", err)
+		}
+		return clearErr
+	}
+
+	if err := writeLockHolder(db, holder); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// GoMigrationChecksum é registrado em gaver_migrations para migrações em Go,
+// cujo código não faz parte de migrations/ e não tem checksum de arquivo.
+const GoMigrationChecksum = "go"
+
+// GoMigrationFunc recebe o *gorm.DB da transação da migração.
+type GoMigrationFunc func(tx *gorm.DB) error
+
+// GoMigration é uma migração de dados escrita em Go, para transformações que
+// precisam da aplicação (hash de senhas, geração de UUIDs, Validate() dos
+// models...).
+type GoMigration struct {
+	Number int
+	Name   string
+	Up     GoMigrationFunc
+	Down   GoMigrationFunc
+}
+
+var goMigrations = map[int]GoMigration{}
+
+// Register registra uma migração em Go com o número informado. O migrate a
+// executa intercalada com os arquivos .sql, em ordem numérica. Deve ser
+// chamada no init() de um pacote do módulo (ex.: modules/blog/migrations),
+// importado pelo executável:
+//
+//	import _ "test/modules/blog/migrations"
+//
+// Arquivos <número>_<nome>.go em migrations/ precisam estar registrados: o
+// ListMigrationFiles falha quando o pacote não foi importado.
+//
+// down pode ser nil quando a migração não puder ser revertida.
+func Register(number int, name string, up, down GoMigrationFunc) {
+	if number <= 0 {
+		panic(fmt.Sprintf("migração Go %q: número inválido %d", name, number))
+	}
+
+	if up == nil {
+		panic(fmt.Sprintf("migração Go %04d_%s: função up não informada", number, name))
+	}
+
+	if existing, ok := goMigrations[number]; ok {
+		panic(fmt.Sprintf("migração Go %04d_%s: número já registrado por %04d_%s", number, name, existing.Number, existing.Name))
+	}
+
+	goMigrations[number] = GoMigration{Number: number, Name: name, Up: up, Down: down}
+}
+
+// registeredMigrationFiles retorna as migrações em Go com número maior que
+// currentTag, no mesmo formato dos arquivos .sql.
+func registeredMigrationFiles(currentTag int) []MigrationFile {
+	var files []MigrationFile
+
+	for number, migration := range goMigrations {
+		if number <= currentTag {
+			continue
+		}
+
+		files = append(files, MigrationFile{
+			Number:   number,
+			Name:     migration.Name,
+			FullName: fmt.Sprintf("%04d_%s (go)", number, migration.Name),
+			Go:       &migration,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Number < files[j].Number
+	})
+
+	return files
+}
+
+// MigrationChecksum retorna o checksum registrado ao aplicar a migração.
+func MigrationChecksum(migrationFile MigrationFile) (string, error) {
+	if migrationFile.Go != nil {
+		return GoMigrationChecksum, nil
+	}
+
+	return FileChecksum(migrationFile.Path)
+}
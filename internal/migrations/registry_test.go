@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// registerForTest registra uma migração em Go e a remove ao fim do teste.
+func registerForTest(t *testing.T, number int, name string) {
+	t.Helper()
+
+	Register(number, name, func(tx *gorm.DB) error { return nil }, nil)
+	t.Cleanup(func() { delete(goMigrations, number) })
+}
+
+// Um arquivo .go em migrations/ cujo pacote não foi importado não pode sumir
+// da lista em silêncio.
+func TestListMigrationFilesGoMigrations(t *testing.T) {
+	dir := t.TempDir()
+	writeMigration(t, dir, 1, "initial", "-- +up\nCREATE TABLE a (id INTEGER);\n")
+	if err := os.WriteFile(filepath.Join(dir, "0002_backfill.go"), []byte("package migrations\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "0002_backfill_test.go"), []byte("package migrations\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ListMigrationFiles(dir, 0); err == nil || !strings.Contains(err.Error(), "0002_backfill.go não está registrada") {
+		t.Errorf("erro = %v, esperado migração em Go não registrada", err)
+	}
+
+	// Já aplicada pela tag legada: não é verificada
+	if _, err := ListMigrationFiles(dir, 2); err != nil {
+		t.Errorf("com tag 2: %v", err)
+	}
+
+	t.Run("nome diferente", func(t *testing.T) {
+		registerForTest(t, 2, "seed")
+		if _, err := ListMigrationFiles(dir, 0); err == nil || !strings.Contains(err.Error(), "registrada como seed") {
+			t.Errorf("erro = %v, esperado nome divergente", err)
+		}
+	})
+
+	t.Run("registrada", func(t *testing.T) {
+		registerForTest(t, 2, "backfill")
+		files, err := ListMigrationFiles(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, file := range files {
+			names = append(names, file.FullName)
+		}
+		if want := "0001_initial.sql,0002_backfill (go)"; strings.Join(names, ",") != want {
+			t.Errorf("arquivos = %v, esperado %s", names, want)
+		}
+	})
+}
@@ -0,0 +1,460 @@
+package migrations
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jinzhu/inflection"
+	"gorm.io/gorm/schema"
+)
+
+// scannedModel guarda, junto com o ModelInfo, o struct de origem e os campos
+// de associação, resolvidos só depois que todos os pacotes foram lidos.
+type scannedModel struct {
+	info      ModelInfo
+	decl      structDecl
+	relations []relationField
+}
+
+// relationField é um campo cujo tipo é outro struct do projeto: User, *User,
+// []Tag ou []*Tag. Ele não vira coluna; define chaves estrangeiras.
+type relationField struct {
+	name     string
+	target   structDecl
+	many     bool
+	settings map[string]string
+}
+
+type foreignKeyCandidate struct {
+	model     *scannedModel
+	key       ForeignKeyInfo
+	belongsTo bool
+}
+
+// relationTarget identifica campos de associação. Campos com as tags type ou
+// serializer são gravados em uma coluna e não são associações.
+func (s *modelScanner) relationTarget(owner structDecl, expr ast.Expr, settings map[string]string) (structDecl, bool, bool) {
+	if _, ok := settings["TYPE"]; ok {
+		return structDecl{}, false, false
+	}
+	if _, ok := settings["SERIALIZER"]; ok {
+		return structDecl{}, false, false
+	}
+
+	many := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if array, ok := expr.(*ast.ArrayType); ok && array.Len == nil {
+		many = true
+		expr = array.Elt
+	}
+
+	target, ok := s.resolveStruct(owner, expr)
+	return target, many, ok
+}
+
+// scannedModelFor retorna o model de um struct. Structs fora dos diretórios
+// escaneados (ex.: referenciados de outro pacote) são montados sob demanda
+// apenas para consulta, sem gerar tabela.
+func (s *modelScanner) scannedModelFor(decl structDecl) (*scannedModel, bool) {
+	if model, ok := s.byStruct[decl.spec]; ok {
+		return model, true
+	}
+
+	if model, ok := s.external[decl.spec]; ok {
+		return model, false
+	}
+
+	model := s.buildModel(decl)
+	s.external[decl.spec] = model
+	return model, false
+}
+
+// resolveRelations converte as associações dos models em chaves estrangeiras
+// e tabelas de junção, seguindo as mesmas regras de inferência do GORM:
+// belongs to (User User + UserID), has one/has many (a chave fica no outro
+// model) e many2many (tabela de junção com a chave dos dois lados).
+func (s *modelScanner) resolveRelations() ([]ModelInfo, []UnmappedField) {
+	var candidates []foreignKeyCandidate
+	var joinTables []ModelInfo
+	var unmapped []UnmappedField
+
+	for _, owner := range s.models {
+		for _, relation := range owner.relations {
+			target, _ := s.scannedModelFor(relation.target)
+
+			if joinTableName := relation.settings["MANY2MANY"]; joinTableName != "" {
+				joinTable, ok := s.joinTable(owner, relation, target, joinTableName)
+				if !ok {
+					unmapped = append(unmapped, relationUnmapped(owner, relation))
+					continue
+				}
+
+				if !containsTable(s.models, joinTables, joinTable.TableName) {
+					joinTables = append(joinTables, joinTable)
+				}
+				continue
+			}
+
+			candidate, ok := s.relationForeignKey(owner, relation, target)
+			if !ok {
+				unmapped = append(unmapped, relationUnmapped(owner, relation))
+				continue
+			}
+
+			if candidate.model != nil && relation.settings["CONSTRAINT"] != "-" {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	// Se os dois lados declaram a associação (User.Orders e Order.User), o
+	// GORM cria apenas a constraint do has one/has many
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return !candidates[i].belongsTo && candidates[j].belongsTo
+	})
+
+	for _, candidate := range candidates {
+		if !hasForeignKey(candidate.model.info, candidate.key) {
+			candidate.model.info.ForeignKeys = append(candidate.model.info.ForeignKeys, candidate.key)
+		}
+	}
+
+	var models []ModelInfo
+	for _, model := range s.models {
+		models = append(models, model.info)
+	}
+
+	return append(models, joinTables...), unmapped
+}
+
+// relationForeignKey tenta belongs to e depois has one para campos simples,
+// e has many para slices. A chave estrangeira só é criada quando a tabela que
+// a recebe foi escaneada.
+func (s *modelScanner) relationForeignKey(owner *scannedModel, relation relationField, target *scannedModel) (foreignKeyCandidate, bool) {
+	guesses := []bool{true, false}
+	if relation.many {
+		guesses = []bool{false}
+	}
+
+	for _, belongsTo := range guesses {
+		primary, foreign := owner, target
+		if belongsTo {
+			primary, foreign = target, owner
+		}
+
+		foreignFields, primaryFields, ok := guessRelationKeys(owner, relation, primary, foreign, belongsTo)
+		if !ok {
+			continue
+		}
+
+		candidate := foreignKeyCandidate{belongsTo: belongsTo}
+		if _, managed := s.byStruct[foreign.decl.spec]; !managed {
+			return candidate, true
+		}
+
+		key := ForeignKeyInfo{
+			Name:     relationConstraintName(owner.info.TableName, relation),
+			RefTable: primary.info.TableName,
+		}
+		key.OnDelete, key.OnUpdate = constraintActions(relation.settings["CONSTRAINT"])
+
+		for i, foreignIndex := range foreignFields {
+			primaryField := primary.info.Fields[primaryFields[i]]
+			copyKeyType(&foreign.info.Fields[foreignIndex], primaryField)
+
+			key.Columns = append(key.Columns, columnName(foreign.info.Fields[foreignIndex]))
+			key.RefColumns = append(key.RefColumns, columnName(primaryField))
+		}
+
+		candidate.model = foreign
+		candidate.key = key
+		return candidate, true
+	}
+
+	return foreignKeyCandidate{}, false
+}
+
+// guessRelationKeys encontra as colunas da chave estrangeira no model
+// foreign e as colunas referenciadas em primary. Sem a tag foreignKey o nome
+// esperado é <Campo><PK> no belongs to e <Model><PK> no has one/has many.
+func guessRelationKeys(owner *scannedModel, relation relationField, primary, foreign *scannedModel, belongsTo bool) ([]int, []int, bool) {
+	var foreignFields, primaryFields []int
+
+	references := tagList(relation.settings["REFERENCES"])
+	candidates := primaryKeyFields(primary.info)
+	if len(references) > 0 {
+		candidates = nil
+		for _, reference := range references {
+			index := lookUpField(primary.info, reference)
+			if index < 0 {
+				return nil, nil, false
+			}
+			candidates = append(candidates, index)
+		}
+	}
+
+	if foreignKeys := tagList(relation.settings["FOREIGNKEY"]); len(foreignKeys) > 0 {
+		for _, foreignKey := range foreignKeys {
+			index := lookUpField(foreign.info, foreignKey)
+			if index < 0 {
+				return nil, nil, false
+			}
+			foreignFields = append(foreignFields, index)
+		}
+
+		if len(candidates) != len(foreignFields) {
+			return nil, nil, false
+		}
+		return foreignFields, candidates, true
+	}
+
+	for _, candidate := range candidates {
+		primaryField := primary.info.Fields[candidate]
+
+		lookUpName := primary.decl.name + primaryField.Name
+		if belongsTo {
+			lookUpName = relation.name + primaryField.Name
+		}
+
+		lookUpNames := []string{lookUpName}
+		if len(candidates) == 1 {
+			base := strings.TrimSuffix(lookUpName, primaryField.Name)
+			lookUpNames = append(lookUpNames, base+"ID", base+"Id")
+		}
+
+		for _, name := range lookUpNames {
+			if index := lookUpField(foreign.info, name); index >= 0 && (foreign != owner || !belongsTo || index != candidate) {
+				foreignFields = append(foreignFields, index)
+				primaryFields = append(primaryFields, candidate)
+				break
+			}
+		}
+	}
+
+	return foreignFields, primaryFields, len(foreignFields) > 0
+}
+
+// joinTable monta a tabela de junção de um campo many2many. As colunas
+// seguem o GORM: <Model><PK> de cada lado (user_id, language_id) e, em
+// associações do model com ele mesmo, o singular do campo (friend_id).
+func (s *modelScanner) joinTable(owner *scannedModel, relation relationField, target *scannedModel, name string) (ModelInfo, bool) {
+	ownFields := primaryKeyFields(owner.info)
+	if foreignKeys := tagList(relation.settings["FOREIGNKEY"]); len(foreignKeys) > 0 {
+		ownFields = nil
+		for _, foreignKey := range foreignKeys {
+			index := lookUpField(owner.info, foreignKey)
+			if index < 0 {
+				return ModelInfo{}, false
+			}
+			ownFields = append(ownFields, index)
+		}
+	}
+
+	referenceFields := primaryKeyFields(target.info)
+	if references := tagList(relation.settings["REFERENCES"]); len(references) > 0 {
+		referenceFields = nil
+		for _, reference := range references {
+			index := lookUpField(target.info, reference)
+			if index < 0 {
+				return ModelInfo{}, false
+			}
+			referenceFields = append(referenceFields, index)
+		}
+	}
+
+	if len(ownFields) == 0 || len(referenceFields) == 0 {
+		return ModelInfo{}, false
+	}
+
+	joinForeignKeys := tagList(relation.settings["JOINFOREIGNKEY"])
+	joinReferences := tagList(relation.settings["JOINREFERENCES"])
+
+	model := ModelInfo{
+		Name:      name,
+		TableName: namingStrategy.JoinTableName(name),
+	}
+
+	ownName := owner.decl.name
+	referenceName := target.decl.name
+	if ownName == referenceName {
+		referenceName = relation.name
+	}
+
+	ownKey := ForeignKeyInfo{Name: joinConstraintName(model.TableName, ownName), RefTable: owner.info.TableName}
+	referenceKey := ForeignKeyInfo{Name: joinConstraintName(model.TableName, referenceName), RefTable: target.info.TableName}
+	ownKey.OnDelete, ownKey.OnUpdate = constraintActions(relation.settings["CONSTRAINT"])
+	referenceKey.OnDelete, referenceKey.OnUpdate = ownKey.OnDelete, ownKey.OnUpdate
+
+	ownNames := make(map[string]bool)
+	for i, index := range ownFields {
+		field := owner.info.Fields[index]
+		fieldName := upperFirst(owner.decl.name) + field.Name
+		if i < len(joinForeignKeys) {
+			fieldName = upperFirst(joinForeignKeys[i])
+		}
+
+		ownNames[fieldName] = true
+		model.Fields = append(model.Fields, joinField(fieldName, field))
+		ownKey.Columns = append(ownKey.Columns, namingStrategy.ColumnName("", fieldName))
+		ownKey.RefColumns = append(ownKey.RefColumns, columnName(field))
+	}
+
+	for i, index := range referenceFields {
+		field := target.info.Fields[index]
+		fieldName := upperFirst(target.decl.name) + field.Name
+		if ownNames[fieldName] {
+			if relation.name != target.decl.name {
+				fieldName = inflection.Singular(relation.name) + field.Name
+			} else {
+				fieldName += "Reference"
+			}
+		}
+		if i < len(joinReferences) {
+			fieldName = upperFirst(joinReferences[i])
+		}
+
+		if !ownNames[fieldName] {
+			model.Fields = append(model.Fields, joinField(fieldName, field))
+		}
+		referenceKey.Columns = append(referenceKey.Columns, namingStrategy.ColumnName("", fieldName))
+		referenceKey.RefColumns = append(referenceKey.RefColumns, columnName(field))
+	}
+
+	if relation.settings["CONSTRAINT"] != "-" {
+		model.ForeignKeys = []ForeignKeyInfo{ownKey, referenceKey}
+	}
+
+	return model, true
+}
+
+// joinField cria a coluna da tabela de junção com o tipo da coluna
+// referenciada. Todas as colunas formam a chave primária.
+func joinField(name string, referenced FieldInfo) FieldInfo {
+	return FieldInfo{
+		Name:         name,
+		Column:       namingStrategy.ColumnName("", name),
+		Type:         referenced.Type,
+		SQLType:      referenced.SQLType,
+		Size:         referenced.Size,
+		Precision:    referenced.Precision,
+		Scale:        referenced.Scale,
+		IsPrimaryKey: true,
+		IsNotNull:    true,
+	}
+}
+
+// copyKeyType faz a coluna da chave estrangeira usar o tipo da coluna
+// referenciada, como o GORM faz ao montar a associação.
+func copyKeyType(foreignField *FieldInfo, primaryField FieldInfo) {
+	foreignField.Type = primaryField.Type
+	foreignField.SQLType = primaryField.SQLType
+	if foreignField.Size == 0 {
+		foreignField.Size = primaryField.Size
+	}
+}
+
+// relationConstraintName usa o nome de constraint:nome,OnDelete:... ou o
+// padrão do GORM, fk_<tabela do dono>_<campo>.
+func relationConstraintName(tableName string, relation relationField) string {
+	constraint := relation.settings["CONSTRAINT"]
+	if idx := strings.IndexByte(constraint, ','); idx != -1 && checkNamePattern.MatchString(constraint[:idx]) {
+		return constraint[:idx]
+	}
+
+	return namingStrategy.RelationshipFKName(schema.Relationship{Name: relation.name, Schema: &schema.Schema{Table: tableName}})
+}
+
+func joinConstraintName(tableName, relationName string) string {
+	return namingStrategy.RelationshipFKName(schema.Relationship{Name: relationName, Schema: &schema.Schema{Table: tableName}})
+}
+
+// constraintActions lê OnDelete e OnUpdate da tag constraint.
+func constraintActions(constraint string) (string, string) {
+	settings := schema.ParseTagSetting(constraint, ",")
+	return strings.ToUpper(settings["ONDELETE"]), strings.ToUpper(settings["ONUPDATE"])
+}
+
+func relationUnmapped(owner *scannedModel, relation relationField) UnmappedField {
+	fieldType := relation.target.name
+	if relation.many {
+		fieldType = "[]" + fieldType
+	}
+
+	return UnmappedField{
+		Model:  owner.info.Name,
+		Field:  relation.name,
+		Type:   fieldType,
+		Reason: "associação sem chave estrangeira identificada; use as tags foreignKey e references",
+	}
+}
+
+// lookUpField procura o campo pelo nome no struct ou pelo nome da coluna.
+func lookUpField(model ModelInfo, name string) int {
+	for i, field := range model.Fields {
+		if field.Name == name || columnName(field) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func primaryKeyFields(model ModelInfo) []int {
+	var indexes []int
+	for i, field := range model.Fields {
+		if field.IsPrimaryKey {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func hasForeignKey(model ModelInfo, key ForeignKeyInfo) bool {
+	for _, existing := range model.ForeignKeys {
+		if strings.EqualFold(existing.Name, key.Name) ||
+			(strings.EqualFold(existing.RefTable, key.RefTable) &&
+				sameColumns(existing.Columns, key.Columns) &&
+				sameColumns(existing.RefColumns, key.RefColumns)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTable(models []*scannedModel, tables []ModelInfo, tableName string) bool {
+	for _, model := range models {
+		if strings.EqualFold(model.info.TableName, tableName) {
+			return true
+		}
+	}
+
+	for _, table := range tables {
+		if strings.EqualFold(table.TableName, tableName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func tagList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func upperFirst(value string) string {
+	runes := []rune(value)
+	if len(runes) == 0 {
+		return value
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,202 @@
+package migrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanRelations(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		table  string
+		want   ForeignKeyInfo
+	}{
+		{
+			name: "belongs to",
+			source: `package models
+
+type User struct {
+	ID uint
+}
+
+type Order struct {
+	ID     uint
+	UserID uint
+	User   User ` + "`gorm:\"constraint:OnDelete:CASCADE\"`" + `
+}
+`,
+			table: "orders",
+			want:  ForeignKeyInfo{Name: "fk_orders_user", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+		},
+		{
+			name: "has one",
+			source: `package models
+
+type User struct {
+	ID      uint
+	Profile Profile
+}
+
+type Profile struct {
+	ID     uint
+	UserID uint
+}
+`,
+			table: "profiles",
+			want:  ForeignKeyInfo{Name: "fk_users_profile", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}},
+		},
+		{
+			name: "has many",
+			source: `package models
+
+type User struct {
+	ID     uint
+	Orders []Order ` + "`gorm:\"constraint:OnUpdate:CASCADE,OnDelete:SET NULL\"`" + `
+}
+
+type Order struct {
+	ID     uint
+	UserID *uint
+}
+`,
+			table: "orders",
+			want:  ForeignKeyInfo{Name: "fk_users_orders", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "SET NULL", OnUpdate: "CASCADE"},
+		},
+		{
+			name: "foreignKey e references",
+			source: `package models
+
+type Company struct {
+	ID   uint
+	Code string ` + "`gorm:\"size:20;unique\"`" + `
+}
+
+type Employee struct {
+	ID          uint
+	CompanyCode string
+	Company     Company ` + "`gorm:\"foreignKey:CompanyCode;references:Code\"`" + `
+}
+`,
+			table: "employees",
+			want:  ForeignKeyInfo{Name: "fk_employees_company", Columns: []string{"company_code"}, RefTable: "companies", RefColumns: []string{"code"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models, unmapped := scanSource(t, tt.source)
+			if len(unmapped) > 0 {
+				t.Errorf("campos não mapeados: %v", unmapped)
+			}
+
+			model := findModel(t, models, tt.table)
+			if len(model.ForeignKeys) != 1 || !reflect.DeepEqual(model.ForeignKeys[0], tt.want) {
+				t.Fatalf("chaves estrangeiras de %s = %+v, esperado %+v", tt.table, model.ForeignKeys, tt.want)
+			}
+
+			// A coluna da chave estrangeira usa o tipo da coluna referenciada
+			column := findColumn(t, model, tt.want.Columns[0])
+			for _, other := range models {
+				if other.TableName != tt.want.RefTable {
+					continue
+				}
+				referenced := findColumn(t, other, tt.want.RefColumns[0])
+				if column.Type != referenced.Type || column.Size != referenced.Size {
+					t.Errorf("coluna %s (%s, size %d) difere da referenciada (%s, size %d)", column.Column, column.Type, column.Size, referenced.Type, referenced.Size)
+				}
+			}
+		})
+	}
+}
+
+// Quando os dois lados declaram a associação, apenas a constraint do has many
+// é criada, como no GORM.
+func TestScanRelationsBothSides(t *testing.T) {
+	models, _ := scanSource(t, `package models
+
+type User struct {
+	ID     uint
+	Orders []Order
+}
+
+type Order struct {
+	ID     uint
+	UserID uint
+	User   User
+}
+`)
+
+	orders := findModel(t, models, "orders")
+	if len(orders.ForeignKeys) != 1 || orders.ForeignKeys[0].Name != "fk_users_orders" {
+		t.Errorf("chaves estrangeiras = %+v, esperado apenas fk_users_orders", orders.ForeignKeys)
+	}
+}
+
+func TestScanManyToMany(t *testing.T) {
+	models, _ := scanSource(t, `package models
+
+type User struct {
+	ID        uint
+	Languages []Language `+"`gorm:\"many2many:user_languages;constraint:OnDelete:CASCADE\"`"+`
+	Friends   []*User    `+"`gorm:\"many2many:user_friends\"`"+`
+}
+
+type Language struct {
+	Code string `+"`gorm:\"primaryKey;size:5\"`"+`
+}
+`)
+
+	joinTable := findModel(t, models, "user_languages")
+	if got := modelColumns(joinTable); strings.Join(got, ",") != "user_id,language_code" {
+		t.Errorf("colunas = %v, esperado [user_id language_code]", got)
+	}
+	if got := primaryKeyColumns(joinTable); strings.Join(got, ",") != "user_id,language_code" {
+		t.Errorf("chave primária = %v, esperado composta por user_id e language_code", got)
+	}
+	if code := findColumn(t, joinTable, "language_code"); code.Type != "string" || code.Size != 5 {
+		t.Errorf("language_code = %+v, esperado o tipo de languages.code", code)
+	}
+
+	wantKeys := []ForeignKeyInfo{
+		{Name: "fk_user_languages_user", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+		{Name: "fk_user_languages_language", Columns: []string{"language_code"}, RefTable: "languages", RefColumns: []string{"code"}, OnDelete: "CASCADE"},
+	}
+	if !reflect.DeepEqual(joinTable.ForeignKeys, wantKeys) {
+		t.Errorf("chaves estrangeiras = %+v, esperado %+v", joinTable.ForeignKeys, wantKeys)
+	}
+
+	// Associação do model com ele mesmo: a segunda coluna usa o singular do campo
+	friends := findModel(t, models, "user_friends")
+	if got := modelColumns(friends); strings.Join(got, ",") != "user_id,friend_id" {
+		t.Errorf("colunas = %v, esperado [user_id friend_id]", got)
+	}
+}
+
+func TestScanRelationUnmapped(t *testing.T) {
+	models, unmapped := scanSource(t, `package models
+
+type Customer struct {
+	ID uint
+}
+
+type Order struct {
+	ID       uint
+	Customer Customer
+}
+`)
+
+	if len(unmapped) != 1 {
+		t.Fatalf("campos não mapeados = %v, esperado apenas Order.Customer", unmapped)
+	}
+
+	want := UnmappedField{Model: "Order", Field: "Customer", Type: "Customer", Reason: "associação sem chave estrangeira identificada; use as tags foreignKey e references"}
+	if unmapped[0] != want {
+		t.Errorf("campo não mapeado = %+v, esperado %+v", unmapped[0], want)
+	}
+
+	if orders := findModel(t, models, "orders"); len(orders.ForeignKeys) > 0 {
+		t.Errorf("orders não deveria ter chaves estrangeiras: %+v", orders.ForeignKeys)
+	}
+}
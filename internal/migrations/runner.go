@@ -0,0 +1,276 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NoTransactionMarker desativa a transação da seção em que aparece, para
+// comandos que o banco não aceita dentro de uma (ex.: VACUUM no SQLite,
+// CREATE INDEX CONCURRENTLY no PostgreSQL). Cada seção é avaliada à parte:
+//
+//	-- +up
+//	-- +notransaction
+//	CREATE INDEX CONCURRENTLY idx_users_email ON users (email);
+//
+//	-- +down
+//	-- +notransaction
+//	DROP INDEX CONCURRENTLY idx_users_email;
+//
+// Antes de -- +up o marcador vale para a seção up, que é onde essas linhas
+// ficam em ParseMigrationSections.
+const NoTransactionMarker = "-- +notransaction"
+
+var (
+	// PRAGMA foreign_keys não tem efeito dentro de uma transação no SQLite
+	foreignKeysPragmaPattern = regexp.MustCompile(`(?i)^PRAGMA\s+foreign_keys\s*=\s*(\w+)\s*;?$`)
+	foreignKeyCheckPattern   = regexp.MustCompile(`(?i)^PRAGMA\s+foreign_key_check\b`)
+)
+
+type StatementError struct {
+	Migration     string
+	Statement     string
+	Transactional bool
+	Err           error
+}
+
+func (e *StatementError) Error() string {
+	if !e.Transactional {
+		return fmt.Sprintf("erro ao executar migração %s: %v\nSQL: %s\nA migração não é transacional: comandos anteriores a este permanecem aplicados", e.Migration, e.Err, e.Statement)
+	}
+
+	return fmt.Sprintf("erro ao executar migração %s: %v\nSQL: %s\nTransação desfeita: nenhuma alteração desta migração foi aplicada", e.Migration, e.Err, e.Statement)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransactional indica se uma seção de migração, como retornada por
+// ParseMigrationSections, deve rodar em uma transação.
+func IsTransactional(section string) bool {
+	for _, line := range strings.Split(section, "\n") {
+		if strings.TrimSpace(line) == NoTransactionMarker {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApplyMigration executa a seção up do arquivo e registra a migração em
+// gaver_migrations. Comandos e registro rodam na mesma transação: se qualquer
+// comando falhar o banco permanece exatamente como estava antes do arquivo,
+// desde que o dialeto suporte DDL transacional.
+func ApplyMigration(db *gorm.DB, dialect Dialect, migrationFile MigrationFile) error {
+	if migrationFile.Go != nil {
+		return applyGoMigration(db, migrationFile)
+	}
+
+	sql, err := ReadMigrationFile(migrationFile.Path)
+	if err != nil {
+		return err
+	}
+
+	checksum, err := MigrationChecksum(migrationFile)
+	if err != nil {
+		return err
+	}
+
+	up, _ := ParseMigrationSections(sql)
+	transactional := IsTransactional(up) && dialect.SupportsTransactionalDDL()
+
+	if len(migrationFile.Replaces) > 0 {
+		return applySquashedMigration(db, dialect, migrationFile, up, checksum, transactional)
+	}
+
+	startedAt := time.Now()
+
+	return runInTransaction(db, dialect, up, transactional, func(tx *gorm.DB) error {
+		if err := execStatements(tx, dialect, migrationFile, up, transactional); err != nil {
+			return err
+		}
+
+		return RecordMigration(tx, migrationFile, checksum, time.Since(startedAt))
+	})
+}
+
+// RevertMigration executa a seção down do arquivo e remove seu registro de
+// gaver_migrations, com a mesma garantia de atomicidade de ApplyMigration.
+func RevertMigration(db *gorm.DB, dialect Dialect, migrationFile MigrationFile) error {
+	if migrationFile.Go != nil {
+		return revertGoMigration(db, migrationFile)
+	}
+
+	sql, err := ReadMigrationFile(migrationFile.Path)
+	if err != nil {
+		return err
+	}
+
+	_, down := ParseMigrationSections(sql)
+	if down == "" {
+		return fmt.Errorf("a migração %s não possui seção %s", migrationFile.FullName, DownMarker)
+	}
+
+	transactional := IsTransactional(down) && dialect.SupportsTransactionalDDL()
+
+	if len(migrationFile.Replaces) > 0 {
+		return revertSquashedMigration(db, dialect, migrationFile, down, transactional)
+	}
+
+	return runInTransaction(db, dialect, down, transactional, func(tx *gorm.DB) error {
+		if err := execStatements(tx, dialect, migrationFile, down, transactional); err != nil {
+			return err
+		}
+
+		return RemoveMigrationRecord(tx, migrationFile.Number)
+	})
+}
+
+// applyGoMigration executa a função up e registra a migração na mesma
+// transação. Em Go sempre há transação, pois a função altera apenas dados.
+func applyGoMigration(db *gorm.DB, migrationFile MigrationFile) error {
+	startedAt := time.Now()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := migrationFile.Go.Up(tx); err != nil {
+			return fmt.Errorf("erro ao executar migração %s: %w\nTransação desfeita: nenhuma alteração desta migração foi aplicada", migrationFile.FullName, err)
+		}
+
+		return RecordMigration(tx, migrationFile, GoMigrationChecksum, time.Since(startedAt))
+	})
+}
+
+func revertGoMigration(db *gorm.DB, migrationFile MigrationFile) error {
+	if migrationFile.Go.Down == nil {
+		return fmt.Errorf("a migração %s não possui função down", migrationFile.FullName)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := migrationFile.Go.Down(tx); err != nil {
+			return fmt.Errorf("erro ao reverter migração %s: %w\nTransação desfeita: nenhuma alteração desta migração foi aplicada", migrationFile.FullName, err)
+		}
+
+		return RemoveMigrationRecord(tx, migrationFile.Number)
+	})
+}
+
+// runInTransaction executa fn dentro de uma transação, quando transactional.
+// Se a seção desativa as chaves estrangeiras (PRAGMA foreign_keys = OFF, gerado
+// pelo SQLite ao recriar tabelas), elas são desativadas antes da transação e
+// restauradas depois dela, na mesma conexão usada por fn.
+func runInTransaction(db *gorm.DB, dialect Dialect, sql string, transactional bool, fn func(tx *gorm.DB) error) error {
+	if !disablesForeignKeys(dialect, sql) {
+		if !transactional {
+			return fn(db)
+		}
+		return db.Transaction(fn)
+	}
+
+	return db.Connection(func(conn *gorm.DB) error {
+		// Nova sessão para que cada comando abaixo comece de um Statement limpo
+		conn = conn.Session(&gorm.Session{})
+
+		var enabled int
+		if err := conn.Raw("PRAGMA foreign_keys").Scan(&enabled).Error; err != nil {
+			return fmt.Errorf("erro ao ler PRAGMA foreign_keys: %w", err)
+		}
+
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return fmt.Errorf("erro ao desativar as chaves estrangeiras: %w", err)
+		}
+
+		var err error
+		if transactional {
+			err = conn.Transaction(fn)
+		} else {
+			err = fn(conn)
+		}
+
+		if restoreErr := conn.Exec(fmt.Sprintf("PRAGMA foreign_keys = %d", enabled)).Error; restoreErr != nil && err == nil {
+			err = fmt.Errorf("erro ao restaurar as chaves estrangeiras: %w", restoreErr)
+		}
+
+		return err
+	})
+}
+
+func disablesForeignKeys(dialect Dialect, sql string) bool {
+	for _, statement := range SplitSQLStatements(dialect, sql) {
+		matches := foreignKeysPragmaPattern.FindStringSubmatch(strings.TrimSpace(statement))
+		if matches == nil {
+			continue
+		}
+
+		switch strings.ToUpper(matches[1]) {
+		case "OFF", "0", "FALSE", "NO":
+			return true
+		}
+	}
+
+	return false
+}
+
+func execStatements(db *gorm.DB, dialect Dialect, migrationFile MigrationFile, sql string, transactional bool) error {
+	for _, statement := range SplitSQLStatements(dialect, sql) {
+		statement = strings.TrimSpace(statement)
+		if statement == "" || statement == ";" {
+			continue
+		}
+
+		// Já tratado por runInTransaction, fora da transação
+		if foreignKeysPragmaPattern.MatchString(statement) {
+			continue
+		}
+
+		var err error
+		if foreignKeyCheckPattern.MatchString(statement) {
+			err = checkForeignKeys(db, statement)
+		} else {
+			err = db.Exec(statement).Error
+		}
+
+		if err != nil {
+			return &StatementError{
+				Migration:     migrationFile.FullName,
+				Statement:     statement,
+				Transactional: transactional,
+				Err:           err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkForeignKeys executa o PRAGMA foreign_key_check e falha se ele
+// encontrar linhas que referenciam registros inexistentes.
+func checkForeignKeys(db *gorm.DB, statement string) error {
+	var violations []struct {
+		Table  string
+		RowID  *int64 `gorm:"column:rowid"`
+		Parent string
+	}
+	if err := db.Raw(statement).Scan(&violations).Error; err != nil {
+		return err
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	var details []string
+	for _, violation := range violations {
+		row := "sem rowid"
+		if violation.RowID != nil {
+			row = fmt.Sprintf("rowid %d", *violation.RowID)
+		}
+		details = append(details, fmt.Sprintf("%s (%s) referencia %s", violation.Table, row, violation.Parent))
+	}
+
+	return fmt.Errorf("chaves estrangeiras inválidas após recriar a tabela: %s", strings.Join(details, "; "))
+}
@@ -0,0 +1,257 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite abre um banco SQLite em um arquivo temporário, com as chaves
+// estrangeiras ativas e a tabela de histórico criada.
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := openEmptySQLite(t)
+	if err := EnsureHistoryTable(db); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// openEmptySQLite abre o banco sem criar gaver_migrations. Um arquivo, e não
+// :memory:, para que todas as conexões do pool vejam o mesmo banco.
+func openEmptySQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+// writeMigration grava o arquivo <número>_<nome>.sql em dir.
+func writeMigration(t *testing.T, dir string, number int, name, content string) MigrationFile {
+	t.Helper()
+
+	fullName := fmt.Sprintf("%04d_%s.sql", number, name)
+	path := filepath.Join(dir, fullName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return MigrationFile{Path: path, Number: number, Name: name, FullName: fullName}
+}
+
+// writeModelMigration grava a migração que leva previous a current.
+func writeModelMigration(t *testing.T, dialect Dialect, dir string, number int, name string, previous, current []ModelInfo) MigrationFile {
+	t.Helper()
+
+	up := GenerateSQL(dialect, DiffModels(previous, current, dialect))
+	down := GenerateSQL(dialect, DiffModels(current, previous, dialect))
+	return writeMigration(t, dir, number, name, FormatMigration(up, down))
+}
+
+func countRows(t *testing.T, db *gorm.DB, table string) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Table(table).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func foreignKeysEnabled(t *testing.T, db *gorm.DB) bool {
+	t.Helper()
+
+	var enabled int
+	if err := db.Raw("PRAGMA foreign_keys").Scan(&enabled).Error; err != nil {
+		t.Fatal(err)
+	}
+	return enabled == 1
+}
+
+// Recriar users não pode apagar em cascata os pedidos que a referenciam.
+func TestApplyMigrationRebuildKeepsReferencingRows(t *testing.T) {
+	db := openSQLite(t)
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+
+	initial := []ModelInfo{goldenUsers(), goldenOrders()}
+	if err := ApplyMigration(db, dialect, writeModelMigration(t, dialect, dir, 1, "initial", nil, initial)); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Exec(`INSERT INTO users (id, name, email) VALUES (1, 'Ana', 'ana@example.com')`)
+	db.Exec(`INSERT INTO orders (id, user_id, total) VALUES (1, 1, 10)`)
+
+	altered := goldenUsers()
+	altered.Fields[1].IsNotNull = false
+	migration := writeModelMigration(t, dialect, dir, 2, "alter_users", initial, []ModelInfo{altered, goldenOrders()})
+
+	if err := ApplyMigration(db, dialect, migration); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := countRows(t, db, "orders"); count != 1 {
+		t.Errorf("orders tem %d linhas após recriar users, esperado 1", count)
+	}
+	if !foreignKeysEnabled(t, db) {
+		t.Error("as chaves estrangeiras deveriam ser reativadas após a migração")
+	}
+
+	if err := RevertMigration(db, dialect, migration); err != nil {
+		t.Fatal(err)
+	}
+	if count := countRows(t, db, "orders"); count != 1 {
+		t.Errorf("orders tem %d linhas após reverter, esperado 1", count)
+	}
+}
+
+// Uma chave estrangeira nova que as linhas existentes violam desfaz a
+// migração inteira.
+func TestApplyMigrationRebuildForeignKeyViolation(t *testing.T) {
+	db := openSQLite(t)
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+
+	withoutForeignKey := goldenOrders()
+	withoutForeignKey.ForeignKeys = nil
+
+	initial := []ModelInfo{goldenUsers(), withoutForeignKey}
+	if err := ApplyMigration(db, dialect, writeModelMigration(t, dialect, dir, 1, "initial", nil, initial)); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Exec(`INSERT INTO orders (id, user_id, total) VALUES (1, 99, 10)`)
+
+	migration := writeModelMigration(t, dialect, dir, 2, "add_fk", initial, []ModelInfo{goldenUsers(), goldenOrders()})
+
+	var statementErr *StatementError
+	if err := ApplyMigration(db, dialect, migration); !errors.As(err, &statementErr) {
+		t.Fatalf("erro = %v, esperado StatementError do foreign_key_check", err)
+	}
+
+	if db.Migrator().HasTable("orders" + rebuildTableSuffix) {
+		t.Error("a tabela temporária deveria ter sido desfeita com a transação")
+	}
+	if count := countRows(t, db, "orders"); count != 1 {
+		t.Errorf("orders tem %d linhas, esperado 1", count)
+	}
+	if !foreignKeysEnabled(t, db) {
+		t.Error("as chaves estrangeiras deveriam ser reativadas após a falha")
+	}
+
+	applied, err := ListAppliedMigrations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("migrações registradas = %d, esperado apenas 0001", len(applied))
+	}
+}
+
+// Colunas NOT NULL novas recebem o valor zero do tipo nas linhas existentes.
+func TestApplyMigrationRebuildZeroValues(t *testing.T) {
+	db := openSQLite(t)
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+
+	initial := []ModelInfo{goldenUsers()}
+	if err := ApplyMigration(db, dialect, writeModelMigration(t, dialect, dir, 1, "initial", nil, initial)); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Exec(`INSERT INTO users (id, name, email) VALUES (1, 'Ana', 'ana@example.com')`)
+
+	withColumns := goldenUsers()
+	withColumns.Fields = append(withColumns.Fields,
+		FieldInfo{Name: "Code", Column: "code", Type: "string", IsNotNull: true},
+		FieldInfo{Name: "Age", Column: "age", Type: "int", IsNotNull: true},
+		FieldInfo{Name: "ExternalID", Column: "external_id", Type: "uuid.UUID", IsNotNull: true},
+		FieldInfo{Name: "ConfirmedAt", Column: "confirmed_at", Type: "time.Time", IsNotNull: true},
+	)
+
+	if err := ApplyMigration(db, dialect, writeModelMigration(t, dialect, dir, 2, "add_columns", initial, []ModelInfo{withColumns})); err != nil {
+		t.Fatal(err)
+	}
+
+	var row struct {
+		Code        string
+		Age         int
+		ExternalID  string
+		ConfirmedAt string
+	}
+	if err := db.Raw("SELECT code, age, external_id, confirmed_at FROM users WHERE id = 1").Scan(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if row.Code != "" || row.Age != 0 || row.ExternalID != "00000000-0000-0000-0000-000000000000" || row.ConfirmedAt != "0001-01-01 00:00:00+00:00" {
+		t.Errorf("valores zero = %+v", row)
+	}
+}
+
+func TestIsTransactional(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		wantUp   bool
+		wantDown bool
+	}{
+		{"sem marcador", "-- +up\nCREATE TABLE a (id INTEGER);\n-- +down\nDROP TABLE a;\n", true, true},
+		{"apenas no up", "-- +up\n" + NoTransactionMarker + "\nVACUUM;\n-- +down\nDROP TABLE a;\n", false, true},
+		{"apenas no down", "-- +up\nCREATE TABLE a (id INTEGER);\n-- +down\n" + NoTransactionMarker + "\nVACUUM;\n", true, false},
+		{"antes do up", NoTransactionMarker + "\n-- +up\nVACUUM;\n-- +down\nDROP TABLE a;\n", false, true},
+		{"nas duas seções", "-- +up\n" + NoTransactionMarker + "\nVACUUM;\n-- +down\n" + NoTransactionMarker + "\nVACUUM;\n", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down := ParseMigrationSections(tt.sql)
+			if got := IsTransactional(up); got != tt.wantUp {
+				t.Errorf("up transacional = %v, esperado %v", got, tt.wantUp)
+			}
+			if got := IsTransactional(down); got != tt.wantDown {
+				t.Errorf("down transacional = %v, esperado %v", got, tt.wantDown)
+			}
+		})
+	}
+}
+
+// VACUUM falha dentro de uma transação: o marcador no up não pode tirar a
+// transação do down, nem o contrário.
+func TestApplyMigrationNoTransactionPerSection(t *testing.T) {
+	db := openSQLite(t)
+	dialect := SQLiteDialect{}
+
+	migration := writeMigration(t, t.TempDir(), 1, "vacuum", "-- +up\n"+NoTransactionMarker+"\nCREATE TABLE a (id INTEGER);\nVACUUM;\n\n-- +down\nDROP TABLE a;\nVACUUM;\n")
+
+	if err := ApplyMigration(db, dialect, migration); err != nil {
+		t.Fatalf("up com %s: %v", NoTransactionMarker, err)
+	}
+
+	var statementErr *StatementError
+	if err := RevertMigration(db, dialect, migration); !errors.As(err, &statementErr) || !statementErr.Transactional {
+		t.Fatalf("erro = %v, esperado VACUUM falhando dentro da transação do down", err)
+	}
+
+	if !db.Migrator().HasTable("a") {
+		t.Error("a transação do down deveria ter desfeito o DROP TABLE")
+	}
+}
@@ -3,33 +3,88 @@ package migrations
 import (
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+
+	"gorm.io/gorm/schema"
 )
 
+// namingStrategy deve ser a mesma usada na conexão com o banco (o padrão do
+// GORM), para que migrações e consultas usem os mesmos nomes.
+var namingStrategy = schema.NamingStrategy{}
+
+var checkNamePattern = regexp.MustCompile(`^[\w-]+$`)
+
 type ModelInfo struct {
-	Name      string
-	TableName string
-	Fields    []FieldInfo
+	Name        string           `json:"name"`
+	TableName   string           `json:"tableName"`
+	Fields      []FieldInfo      `json:"fields"`
+	ForeignKeys []ForeignKeyInfo `json:"foreignKeys,omitempty"`
+	Checks      []CheckInfo      `json:"checks,omitempty"`
+	// Índices com nome, compostos ou com opções; os das tags index e unique
+	// sem opções ficam nos campos
+	Indexes []IndexInfo `json:"indexes,omitempty"`
 }
 
 type FieldInfo struct {
-	Name         string
-	Type         string
-	SQLType      string
-	IsPrimaryKey bool
-	IsNotNull    bool
-	IsUnique     bool
-	IsIndex      bool
-	DefaultValue string
-	Tags         map[string]string
+	Name          string `json:"name"`
+	Column        string `json:"column,omitempty"`
+	Type          string `json:"type"`
+	SQLType       string `json:"sqlType,omitempty"`
+	IsPrimaryKey  bool   `json:"isPrimaryKey"`
+	IsNotNull     bool   `json:"isNotNull"`
+	IsUnique      bool   `json:"isUnique"`
+	IsIndex       bool   `json:"isIndex"`
+	DefaultValue  string `json:"defaultValue,omitempty"`
+	Size          int    `json:"size,omitempty"`
+	Precision     int    `json:"precision,omitempty"`
+	Scale         int    `json:"scale,omitempty"`
+	AutoIncrement bool   `json:"autoIncrement,omitempty"`
+	Comment       string `json:"comment,omitempty"`
+	// Faz parte de um índice de ModelInfo.Indexes; no MySQL strings indexadas
+	// precisam de tamanho fixo
+	InIndex bool `json:"inIndex,omitempty"`
+	// Expressão da tag check; no schema as checks ficam em ModelInfo.Checks
+	Check string            `json:"-"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	// Tags index e uniqueIndex com opções; no schema ficam em ModelInfo.Indexes
+	indexes []fieldIndex
+}
+
+// fieldIndex é uma tag index:nome,opções de um campo. Campos com o mesmo
+// nome de índice formam um índice composto.
+type fieldIndex struct {
+	name      string
+	composite string
+	unique    bool
+	where     string
+	sort      string
+	priority  int
+}
+
+// ScanModels retorna os models do diretório e os campos cujo tipo não pôde
+// ser mapeado para uma coluna.
+func ScanModels(directory string) ([]ModelInfo, []UnmappedField, error) {
+	scanner := newModelScanner()
+	if err := scanner.scanDirectory(directory); err != nil {
+		return nil, nil, err
+	}
+
+	models, unmapped := scanner.result()
+	return models, unmapped, nil
 }
 
-func ScanModels(directory string) ([]ModelInfo, error) {
-	var models []ModelInfo
+// scanDirectory lê os pacotes do diretório e acumula seus models no scanner.
+// As associações só são resolvidas em result, para que models de diretórios
+// diferentes possam se referenciar.
+func (s *modelScanner) scanDirectory(directory string) error {
+	var packages []string
+	packageFiles := make(map[string][]string)
 
 	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -40,88 +95,222 @@ func ScanModels(directory string) ([]ModelInfo, error) {
 			return nil
 		}
 
-		fileModels, err := parseGoFile(path)
-		if err != nil {
-			return fmt.Errorf("erro ao parsear arquivo %s: %w", path, err)
+		dir := filepath.Dir(path)
+		if _, ok := packageFiles[dir]; !ok {
+			packages = append(packages, dir)
 		}
-
-		models = append(models, fileModels...)
+		packageFiles[dir] = append(packageFiles[dir], path)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return models, err
-}
+	for _, dir := range packages {
+		pkg, err := s.parsePackage(dir, packageFiles[dir])
+		if err != nil {
+			return err
+		}
 
-func parseGoFile(filePath string) ([]ModelInfo, error) {
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
-	if err != nil {
-		return nil, err
+		for _, model := range s.packageModels(pkg) {
+			if _, ok := s.byStruct[model.decl.spec]; ok {
+				continue
+			}
+			s.models = append(s.models, model)
+			s.byStruct[model.decl.spec] = model
+		}
 	}
 
-	var models []ModelInfo
+	return nil
+}
+
+// result resolve as associações e retorna os models com suas chaves
+// estrangeiras, as tabelas de junção e os campos não mapeados.
+func (s *modelScanner) result() ([]ModelInfo, []UnmappedField) {
+	models, unmappedRelations := s.resolveRelations()
+	return models, append(unmappedFields(models), unmappedRelations...)
+}
+
+func unmappedFields(models []ModelInfo) []UnmappedField {
+	var unmapped []UnmappedField
 
-	ast.Inspect(node, func(n ast.Node) bool {
-		ts, ok := n.(*ast.TypeSpec)
-		if !ok {
-			return true
+	for _, model := range models {
+		for _, field := range model.Fields {
+			if field.SQLType == "" && !knownFieldType(field.Type) {
+				unmapped = append(unmapped, UnmappedField{Model: model.Name, Field: field.Name, Type: field.Type})
+			}
 		}
+	}
+
+	return unmapped
+}
+
+func (s *modelScanner) packageModels(pkg *scannedPackage) []*scannedModel {
+	var models []*scannedModel
+	embedded := embeddedStructs(pkg)
 
-		st, ok := ts.Type.(*ast.StructType)
-		if !ok {
-			return true
+	for _, decl := range pkg.structs {
+		if embedded[decl.name] {
+			continue
 		}
 
-		modelInfo := ModelInfo{
-			Name:      ts.Name.Name,
-			TableName: getTableName(ts.Name.Name),
-			Fields:    []FieldInfo{},
+		if model := s.buildModel(decl); len(model.info.Fields) > 0 {
+			models = append(models, model)
 		}
+	}
 
-		for _, field := range st.Fields.List {
-			if len(field.Names) == 0 {
-				continue
-			}
+	return models
+}
+
+func (s *modelScanner) buildModel(decl structDecl) *scannedModel {
+	tableName, ok := decl.pkg.tableNames[decl.name]
+	if !ok {
+		tableName = getTableName(decl.name)
+	}
 
-			fieldInfo := parseField(field)
-			if fieldInfo != nil {
-				modelInfo.Fields = append(modelInfo.Fields, *fieldInfo)
+	fields, relations := s.structFields(decl, "", make(map[*ast.StructType]bool))
+	setDefaultPrimaryKey(fields)
+
+	modelInfo := ModelInfo{
+		Name:      decl.name,
+		TableName: tableName,
+		Fields:    fields,
+	}
+	modelInfo.Checks = fieldChecks(tableName, modelInfo.Fields)
+	modelInfo.Indexes = fieldIndexes(tableName, modelInfo.Fields)
+
+	for _, index := range modelInfo.Indexes {
+		for i := range modelInfo.Fields {
+			for _, column := range index.Columns {
+				if strings.EqualFold(columnName(modelInfo.Fields[i]), column) {
+					modelInfo.Fields[i].InIndex = true
+				}
 			}
 		}
+	}
 
-		if len(modelInfo.Fields) > 0 {
-			models = append(models, modelInfo)
+	return &scannedModel{info: modelInfo, decl: decl, relations: relations}
+}
+
+// setDefaultPrimaryKey aplica a convenção do GORM: sem a tag primaryKey, a
+// coluna id é a chave primária.
+func setDefaultPrimaryKey(fields []FieldInfo) {
+	for _, field := range fields {
+		if field.IsPrimaryKey {
+			return
 		}
+	}
 
-		return true
-	})
+	for i := range fields {
+		if columnName(fields[i]) == "id" {
+			fields[i].IsPrimaryKey = true
+			return
+		}
+	}
+}
+
+// findTableNameMethods encontra métodos TableName() string que retornam uma
+// string literal, indexados pelo nome do tipo receptor. Métodos que calculam o
+// nome em tempo de execução não podem ser resolvidos e são ignorados.
+func findTableNameMethods(node *ast.File) map[string]string {
+	tableNames := make(map[string]string)
 
-	return models, nil
+	for _, decl := range node.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || fn.Name.Name != "TableName" || fn.Body == nil {
+			continue
+		}
+
+		if fn.Type.Params.NumFields() != 0 || fn.Type.Results.NumFields() != 1 {
+			continue
+		}
+
+		receiver := fn.Recv.List[0].Type
+		if star, ok := receiver.(*ast.StarExpr); ok {
+			receiver = star.X
+		}
+
+		ident, ok := receiver.(*ast.Ident)
+		if !ok || len(fn.Body.List) != 1 {
+			continue
+		}
+
+		ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			continue
+		}
+
+		literal, ok := ret.Results[0].(*ast.BasicLit)
+		if !ok || literal.Kind != token.STRING {
+			continue
+		}
+
+		tableName, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			continue
+		}
+
+		tableNames[ident.Name] = tableName
+	}
+
+	return tableNames
 }
 
-func parseField(field *ast.Field) *FieldInfo {
+// parseField monta a coluna de um campo com o tipo já resolvido. A coluna é
+// NOT NULL, exceto para tipos anuláveis (ponteiros, sql.Null*,
+// gorm.DeletedAt); a tag not null sempre prevalece.
+func parseField(field *ast.Field, fieldType string, nullable bool) *FieldInfo {
 	if len(field.Names) == 0 {
 		return nil
 	}
 
 	fieldInfo := &FieldInfo{
-		Name:  field.Names[0].Name,
-		Tags:  make(map[string]string),
-		Type:  getTypeName(field.Type),
-		IsNotNull: true,
+		Name:      field.Names[0].Name,
+		Column:    namingStrategy.ColumnName("", field.Names[0].Name),
+		Tags:      make(map[string]string),
+		Type:      fieldType,
+		IsNotNull: !nullable,
 	}
 
 	if field.Tag != nil {
 		tagValue := strings.Trim(field.Tag.Value, "`")
-		tags := parseStructTag(tagValue)
-		fieldInfo.Tags = tags
+		fieldInfo.Tags = parseStructTag(tagValue)
+		parseGormTag(gormTagSettings(field), fieldInfo)
+		fieldInfo.indexes = parseFieldIndexes(gormTag(field))
+	}
 
-		if gormTag, ok := tags["gorm"]; ok {
-			parseGormTag(gormTag, fieldInfo)
+	return fieldInfo
+}
+
+// ignoredField indica se o GORM deixa o campo fora da tabela: campos não
+// exportados e campos com gorm:"-", "-:all" ou "-:migration".
+func ignoredField(field *ast.Field, gormSettings map[string]string) bool {
+	name := ""
+	if len(field.Names) > 0 {
+		name = field.Names[0].Name
+	} else {
+		fieldType := field.Type
+		if star, ok := fieldType.(*ast.StarExpr); ok {
+			fieldType = star.X
+		}
+		switch t := fieldType.(type) {
+		case *ast.Ident:
+			name = t.Name
+		case *ast.SelectorExpr:
+			name = t.Sel.Name
 		}
 	}
 
-	return fieldInfo
+	if !ast.IsExported(name) {
+		return true
+	}
+
+	switch strings.ToLower(gormSettings["-"]) {
+	case "-", "all", "migration":
+		return true
+	}
+
+	return false
 }
 
 func parseStructTag(tag string) map[string]string {
@@ -139,50 +328,181 @@ func parseStructTag(tag string) map[string]string {
 	return tags
 }
 
-func parseGormTag(tag string, fieldInfo *FieldInfo) {
-	parts := strings.Split(tag, ";")
-	
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		
-		if part == "primary_key" || part == "primaryKey" {
+// parseGormTag aplica as opções da tag gorm que afetam a definição da coluna.
+// As chaves chegam em maiúsculas, como em schema.ParseTagSetting. Opções que
+// só mudam o comportamento em tempo de execução (autoCreateTime,
+// autoUpdateTime, serializer...) não alteram o DDL e são ignoradas.
+func parseGormTag(settings map[string]string, fieldInfo *FieldInfo) {
+	for key, value := range settings {
+		switch key {
+		case "PRIMARY_KEY", "PRIMARYKEY":
 			fieldInfo.IsPrimaryKey = true
-		}
-		if part == "not null" || part == "notnull" {
+		case "NOT NULL", "NOTNULL":
 			fieldInfo.IsNotNull = true
-		}
-		if strings.HasPrefix(part, "unique") {
+		case "UNIQUE":
 			fieldInfo.IsUnique = true
+		case "UNIQUEINDEX":
+			// Com nome ou opções o índice é lido por parseFieldIndexes
+			fieldInfo.IsUnique = value == key
+		case "INDEX":
+			fieldInfo.IsIndex = value == key
+		case "TYPE":
+			fieldInfo.SQLType = value
+		case "DEFAULT":
+			fieldInfo.DefaultValue = value
+		case "COLUMN":
+			fieldInfo.Column = value
+		case "SIZE":
+			fieldInfo.Size, _ = strconv.Atoi(value)
+		case "PRECISION":
+			fieldInfo.Precision, _ = strconv.Atoi(value)
+		case "SCALE":
+			fieldInfo.Scale, _ = strconv.Atoi(value)
+		case "AUTOINCREMENT":
+			fieldInfo.AutoIncrement = !strings.EqualFold(value, "false")
+		case "COMMENT":
+			fieldInfo.Comment = value
+		case "CHECK":
+			fieldInfo.Check = value
 		}
-		if part == "index" {
-			fieldInfo.IsIndex = true
+	}
+}
+
+// fieldChecks converte as tags check dos campos em constraints da tabela,
+// com o mesmo nome que o GORM usaria: o informado em check:nome,expressão ou
+// chk_<tabela>_<coluna>.
+func fieldChecks(tableName string, fields []FieldInfo) []CheckInfo {
+	var checks []CheckInfo
+
+	for _, field := range fields {
+		if field.Check == "" {
+			continue
 		}
-		if strings.HasPrefix(part, "type:") {
-			fieldInfo.SQLType = strings.TrimPrefix(part, "type:")
+
+		name := namingStrategy.CheckerName(tableName, columnName(field))
+		expression := field.Check
+
+		parts := strings.Split(field.Check, ",")
+		if len(parts) > 1 && checkNamePattern.MatchString(parts[0]) {
+			name = parts[0]
+			expression = strings.Join(parts[1:], ",")
+		} else if parts[0] == "" {
+			expression = strings.Join(parts[1:], ",")
 		}
-		if strings.HasPrefix(part, "default:") {
-			fieldInfo.DefaultValue = strings.TrimPrefix(part, "default:")
+
+		checks = append(checks, CheckInfo{Name: name, Expression: strings.TrimSpace(expression)})
+	}
+
+	return checks
+}
+
+// parseFieldIndexes lê as tags index e uniqueIndex que têm nome ou opções
+// (index:idx_nome,unique,sort:desc,where:...,priority:1), com a mesma sintaxe
+// do GORM. Cada campo pode ter várias dessas tags.
+func parseFieldIndexes(tag string) []fieldIndex {
+	var indexes []fieldIndex
+
+	for _, part := range strings.Split(tag, ";") {
+		values := strings.Split(part, ":")
+		key := strings.TrimSpace(strings.ToUpper(values[0]))
+		if key != "INDEX" && key != "UNIQUEINDEX" {
+			continue
+		}
+
+		value := strings.Join(values[1:], ":")
+		if value == "" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(value, ",")
+		settings := schema.ParseTagSetting(options, ",")
+
+		priority, err := strconv.Atoi(settings["PRIORITY"])
+		if err != nil {
+			priority = 10
 		}
+
+		indexes = append(indexes, fieldIndex{
+			name:      strings.TrimSpace(name),
+			composite: settings["COMPOSITE"],
+			unique:    key == "UNIQUEINDEX" || settings["UNIQUE"] != "" || strings.EqualFold(settings["CLASS"], "UNIQUE"),
+			where:     settings["WHERE"],
+			sort:      strings.ToUpper(settings["SORT"]),
+			priority:  priority,
+		})
 	}
+
+	return indexes
 }
 
-func getTypeName(expr ast.Expr) string {
-	switch t := expr.(type) {
-	case *ast.Ident:
-		return t.Name
-	case *ast.SelectorExpr:
-		return fmt.Sprintf("%s.%s", getTypeName(t.X), t.Sel.Name)
-	case *ast.ArrayType:
-		return "[]" + getTypeName(t.Elt)
-	case *ast.MapType:
-		return fmt.Sprintf("map[%s]%s", getTypeName(t.Key), getTypeName(t.Value))
-	default:
-		return "unknown"
+// fieldIndexes agrupa as tags de índice dos campos pelo nome. Sem nome, o
+// índice recebe o nome do GORM, idx_<tabela>_<campo> (ou da tag composite).
+// As colunas de um índice composto seguem a priority e, em caso de empate, a
+// ordem dos campos.
+func fieldIndexes(tableName string, fields []FieldInfo) []IndexInfo {
+	type indexColumn struct {
+		column   string
+		sort     string
+		priority int
+	}
+
+	var indexes []IndexInfo
+	var columns [][]indexColumn
+	byName := make(map[string]int)
+
+	for _, field := range fields {
+		for _, tag := range field.indexes {
+			name := tag.name
+			if name == "" {
+				subName := field.Name
+				if tag.composite != "" {
+					subName = tag.composite
+				}
+				name = namingStrategy.IndexName(tableName, subName)
+			}
+
+			position, ok := byName[name]
+			if !ok {
+				position = len(indexes)
+				byName[name] = position
+				indexes = append(indexes, IndexInfo{Name: name, Table: tableName})
+				columns = append(columns, nil)
+			}
+
+			index := &indexes[position]
+			index.Unique = index.Unique || tag.unique
+			if index.Where == "" {
+				index.Where = tag.where
+			}
+
+			columns[position] = append(columns[position], indexColumn{column: columnName(field), sort: tag.sort, priority: tag.priority})
+		}
+	}
+
+	for i := range indexes {
+		sort.SliceStable(columns[i], func(a, b int) bool {
+			return columns[i][a].priority < columns[i][b].priority
+		})
+
+		sorted := false
+		for _, column := range columns[i] {
+			indexes[i].Columns = append(indexes[i].Columns, column.column)
+			indexes[i].Sorts = append(indexes[i].Sorts, column.sort)
+			sorted = sorted || column.sort != ""
+		}
+
+		if !sorted {
+			indexes[i].Sorts = nil
+		}
 	}
+
+	return indexes
 }
 
+// getTableName aplica a mesma regra do GORM para structs sem TableName():
+// snake_case no plural (OrderItem -> order_items).
 func getTableName(structName string) string {
-	return strings.ToLower(structName) + "s"
+	return namingStrategy.TableName(structName)
 }
 
 // FindModelDirectories percorre a pasta modules e encontra todos os diretórios models
@@ -219,27 +539,27 @@ func FindModelDirectories() ([]string, error) {
 }
 
 // ScanModelsFromModules escaneia todos os diretórios models encontrados em modules
-// e retorna uma lista consolidada de todos os models encontrados
-func ScanModelsFromModules() ([]ModelInfo, error) {
-	var allModels []ModelInfo
-
+// e retorna uma lista consolidada de todos os models encontrados, junto com os
+// campos que não puderam ser mapeados
+func ScanModelsFromModules() ([]ModelInfo, []UnmappedField, error) {
 	modelDirs, err := FindModelDirectories()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if len(modelDirs) == 0 {
-		return allModels, nil
+		return nil, nil, nil
 	}
 
+	// Um único scanner para todos os módulos, já que associações podem
+	// apontar para models de outro módulo
+	scanner := newModelScanner()
 	for _, dir := range modelDirs {
-		models, err := ScanModels(dir)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear %s: %w", dir, err)
+		if err := scanner.scanDirectory(dir); err != nil {
+			return nil, nil, fmt.Errorf("erro ao escanear %s: %w", dir, err)
 		}
-		allModels = append(allModels, models...)
 	}
 
-	return allModels, nil
+	models, unmapped := scanner.result()
+	return models, unmapped, nil
 }
-
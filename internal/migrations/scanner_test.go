@@ -0,0 +1,317 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scanSources grava os arquivos em um diretório temporário e os escaneia como
+// um pacote de models.
+func scanSources(t *testing.T, files map[string]string) ([]ModelInfo, []UnmappedField) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	models, unmapped, err := ScanModels(dir)
+	if err != nil {
+		t.Fatalf("ScanModels: %v", err)
+	}
+
+	return models, unmapped
+}
+
+func scanSource(t *testing.T, source string) ([]ModelInfo, []UnmappedField) {
+	t.Helper()
+	return scanSources(t, map[string]string{"models.go": source})
+}
+
+func findModel(t *testing.T, models []ModelInfo, tableName string) ModelInfo {
+	t.Helper()
+
+	for _, model := range models {
+		if model.TableName == tableName {
+			return model
+		}
+	}
+
+	var tables []string
+	for _, model := range models {
+		tables = append(tables, model.TableName)
+	}
+	t.Fatalf("tabela %s não encontrada; tabelas: %v", tableName, tables)
+	return ModelInfo{}
+}
+
+func findColumn(t *testing.T, model ModelInfo, column string) FieldInfo {
+	t.Helper()
+
+	field, ok := findField(model, column)
+	if !ok {
+		t.Fatalf("coluna %s.%s não encontrada; colunas: %v", model.TableName, column, modelColumns(model))
+	}
+
+	return field
+}
+
+func modelColumns(model ModelInfo) []string {
+	var columns []string
+	for _, field := range model.Fields {
+		columns = append(columns, columnName(field))
+	}
+	return columns
+}
+
+func TestScanTableNames(t *testing.T) {
+	models, _ := scanSources(t, map[string]string{
+		"models.go": `package models
+
+type Person struct {
+	ID uint
+}
+
+type Child struct {
+	ID uint
+}
+
+type Category struct {
+	ID uint
+}
+
+type OrderItem struct {
+	ID uint
+}
+
+type Invoice struct {
+	ID uint
+}
+
+type Account struct {
+	ID uint
+}
+`,
+		// TableName() em outro arquivo do pacote, com receptor valor e ponteiro
+		"tables.go": `package models
+
+func (Invoice) TableName() string {
+	return "billing_invoices"
+}
+
+func (a *Account) TableName() string {
+	return "contas"
+}
+`,
+	})
+
+	tests := []struct {
+		model string
+		table string
+	}{
+		{"Person", "people"},
+		{"Child", "children"},
+		{"Category", "categories"},
+		{"OrderItem", "order_items"},
+		{"Invoice", "billing_invoices"},
+		{"Account", "contas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			for _, model := range models {
+				if model.Name == tt.model {
+					if model.TableName != tt.table {
+						t.Errorf("tabela de %s = %s, esperado %s", tt.model, model.TableName, tt.table)
+					}
+					return
+				}
+			}
+			t.Fatalf("model %s não encontrado", tt.model)
+		})
+	}
+}
+
+// Um TableName() que não retorna uma string literal não pode ser resolvido e
+// o nome padrão é usado.
+func TestScanTableNameNotLiteral(t *testing.T) {
+	models, _ := scanSource(t, `package models
+
+var prefix = "app_"
+
+type Product struct {
+	ID uint
+}
+
+func (Product) TableName() string {
+	return prefix + "products"
+}
+`)
+
+	findModel(t, models, "products")
+}
+
+func TestScanColumnNames(t *testing.T) {
+	models, _ := scanSource(t, `package models
+
+type Order struct {
+	ID          uint
+	UserID      uint
+	APIKey      string
+	HTTPStatus  int
+	ExternalURL string
+	CreatedAt   int64
+	Total       float64 `+"`gorm:\"column:valor_total\"`"+`
+	internal    string
+	Ignored     string `+"`gorm:\"-\"`"+`
+	Migration   string `+"`gorm:\"-:migration\"`"+`
+}
+`)
+
+	order := findModel(t, models, "orders")
+
+	want := []string{"id", "user_id", "api_key", "http_status", "external_url", "created_at", "valor_total"}
+	if got := modelColumns(order); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("colunas = %v, esperado %v", got, want)
+	}
+
+	if !findColumn(t, order, "id").IsPrimaryKey {
+		t.Error("id deveria ser a chave primária por convenção")
+	}
+}
+
+func TestScanFieldTypes(t *testing.T) {
+	models, unmapped := scanSource(t, `package models
+
+import (
+	"database/sql"
+	"time"
+
+	gouuid "github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type Status string
+
+type Level int
+
+type Meta struct {
+	Source string
+}
+
+type Profile struct {
+	ID         uint
+	Name       string
+	Nickname   *string
+	Email      sql.NullString
+	Age        sql.NullInt64
+	Score      sql.Null[float64]
+	Status     Status
+	Level      *Level
+	Required   *string `+"`gorm:\"not null\"`"+`
+	BirthDate  *time.Time
+	ExternalID gouuid.UUID
+	Meta       datatypes.JSONType[Meta]
+	Avatar     []byte
+	DeletedAt  gorm.DeletedAt
+	Channel    chan int
+}
+`)
+
+	profile := findModel(t, models, "profiles")
+
+	tests := []struct {
+		column    string
+		fieldType string
+		notNull   bool
+	}{
+		{"name", "string", true},
+		{"nickname", "string", false},
+		{"email", "string", false},
+		{"age", "int64", false},
+		{"score", "float64", false},
+		{"status", "string", true},
+		{"level", "int", false},
+		{"required", "string", true},
+		{"birth_date", "time.Time", false},
+		{"external_id", "uuid.UUID", true},
+		{"meta", "datatypes.JSONType", true},
+		{"avatar", "[]byte", true},
+		{"deleted_at", "gorm.DeletedAt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			field := findColumn(t, profile, tt.column)
+			if field.Type != tt.fieldType {
+				t.Errorf("tipo = %s, esperado %s", field.Type, tt.fieldType)
+			}
+			if field.IsNotNull != tt.notNull {
+				t.Errorf("IsNotNull = %v, esperado %v", field.IsNotNull, tt.notNull)
+			}
+		})
+	}
+
+	if len(unmapped) != 1 || unmapped[0].Field != "Channel" {
+		t.Errorf("apenas Channel deveria ficar sem mapeamento: %v", unmapped)
+	}
+}
+
+func TestScanGormTags(t *testing.T) {
+	models, _ := scanSource(t, `package models
+
+type Product struct {
+	Code     string  `+"`gorm:\"primaryKey;type:varchar(20)\"`"+`
+	Name     string  `+"`gorm:\"size:100;not null;unique;comment:Nome exibido\"`"+`
+	Price    float64 `+"`gorm:\"precision:10;scale:2;default:0\"`"+`
+	Status   string  `+"`gorm:\"default:'ativo';index\"`"+`
+	Stock    int     `+"`gorm:\"check:stock >= 0\"`"+`
+	Discount int     `+"`gorm:\"check:discount_range,discount BETWEEN 0 AND 100\"`"+`
+	Sequence int     `+"`gorm:\"autoIncrement\"`"+`
+}
+`)
+
+	product := findModel(t, models, "products")
+
+	code := findColumn(t, product, "code")
+	if !code.IsPrimaryKey || code.SQLType != "varchar(20)" {
+		t.Errorf("code = %+v, esperado chave primária varchar(20)", code)
+	}
+
+	name := findColumn(t, product, "name")
+	if name.Size != 100 || !name.IsNotNull || !name.IsUnique || name.Comment != "Nome exibido" {
+		t.Errorf("name = %+v, esperado size 100, NOT NULL, UNIQUE e comentário", name)
+	}
+
+	price := findColumn(t, product, "price")
+	if price.Precision != 10 || price.Scale != 2 || price.DefaultValue != "0" {
+		t.Errorf("price = %+v, esperado NUMERIC(10,2) com default 0", price)
+	}
+
+	status := findColumn(t, product, "status")
+	if status.DefaultValue != "'ativo'" || !status.IsIndex {
+		t.Errorf("status = %+v, esperado default 'ativo' e índice", status)
+	}
+
+	if !findColumn(t, product, "sequence").AutoIncrement {
+		t.Error("sequence deveria ter autoIncrement")
+	}
+
+	wantChecks := []CheckInfo{
+		{Name: "chk_products_stock", Expression: "stock >= 0"},
+		{Name: "discount_range", Expression: "discount BETWEEN 0 AND 100"},
+	}
+	if len(product.Checks) != len(wantChecks) {
+		t.Fatalf("checks = %+v, esperado %+v", product.Checks, wantChecks)
+	}
+	for i, check := range wantChecks {
+		if product.Checks[i] != check {
+			t.Errorf("check %d = %+v, esperado %+v", i, product.Checks[i], check)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type SchemaSnapshot struct {
+	MigrationNumber int         `json:"migrationNumber"`
+	MigrationName   string      `json:"migrationName"`
+	Models          []ModelInfo `json:"models"`
+}
+
+// SnapshotPath retorna o caminho do snapshot que acompanha um arquivo de
+// migração, por exemplo migrations/0003_users.snapshot.json.
+func SnapshotPath(migrationPath string) string {
+	return strings.TrimSuffix(migrationPath, ".sql") + ".snapshot.json"
+}
+
+func WriteSnapshot(migrationPath string, snapshot SchemaSnapshot) error {
+	content, err := json.MarshalIndent(snapshot, "", "    ")
+	if err != nil {
+		return fmt.Errorf("erro ao codificar snapshot: %w", err)
+	}
+
+	if err := os.WriteFile(SnapshotPath(migrationPath), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("erro ao escrever snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func ReadSnapshot(snapshotPath string) (*SchemaSnapshot, error) {
+	content, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler snapshot: %w", err)
+	}
+
+	var snapshot SchemaSnapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar snapshot %s: %w", filepath.Base(snapshotPath), err)
+	}
+
+	return &snapshot, nil
+}
@@ -0,0 +1,260 @@
+package migrations
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Palavras que, logo após CREATE, indicam um comando composto cujo corpo
+// BEGIN ... END contém outros comandos terminados em ponto e vírgula.
+var compoundStatementKeywords = map[string]bool{
+	"TRIGGER":   true,
+	"PROCEDURE": true,
+	"FUNCTION":  true,
+	"EVENT":     true,
+}
+
+// Blocos cujo END é seguido da própria palavra (END IF, END LOOP...) e que,
+// por isso, não alteram a contagem de BEGIN/END.
+var blockEndQualifiers = map[string]bool{
+	"IF":     true,
+	"LOOP":   true,
+	"WHILE":  true,
+	"REPEAT": true,
+}
+
+// SplitSQLStatements divide um script SQL em comandos individuais. Strings,
+// identificadores entre aspas, comentários de linha e de bloco, strings com
+// dollar quoting ($$ ... $$) e corpos BEGIN ... END de triggers e funções são
+// respeitados: um ponto e vírgula dentro deles não encerra o comando. No MySQL,
+// \' dentro de uma string também não a encerra. Comentários são removidos e
+// cada comando retornado termina com ";".
+func SplitSQLStatements(dialect Dialect, sql string) []string {
+	splitter := &sqlSplitter{input: []rune(sql), backslashEscapes: dialect.SupportsBackslashEscapes()}
+	return splitter.split()
+}
+
+type sqlSplitter struct {
+	input      []rune
+	pos        int
+	current    strings.Builder
+	statements []string
+
+	// No MySQL, \ escapa o caractere seguinte dentro de strings
+	backslashEscapes bool
+
+	// Palavras iniciais do comando atual, usadas para detectar comandos compostos
+	leadingWords []string
+	compound     bool
+	depth        int
+	skipCase     bool
+}
+
+func (s *sqlSplitter) split() []string {
+	for s.pos < len(s.input) {
+		r := s.input[s.pos]
+
+		switch {
+		case r == '-' && s.peek(1) == '-':
+			s.skipLineComment()
+		case r == '/' && s.peek(1) == '*':
+			s.skipBlockComment()
+		case r == '\'' || r == '"' || r == '`':
+			s.copyQuoted(r, r)
+		case r == '[':
+			s.copyQuoted('[', ']')
+		case r == '$' && s.dollarTag() != "":
+			s.copyDollarQuoted(s.dollarTag())
+		case isWordStart(r):
+			s.copyWord()
+		case r == ';':
+			if s.depth > 0 {
+				s.current.WriteRune(r)
+				s.pos++
+				continue
+			}
+			s.pos++
+			s.finishStatement()
+		default:
+			s.current.WriteRune(r)
+			s.pos++
+		}
+	}
+
+	s.finishStatement()
+	return s.statements
+}
+
+func (s *sqlSplitter) peek(offset int) rune {
+	if s.pos+offset >= len(s.input) {
+		return 0
+	}
+	return s.input[s.pos+offset]
+}
+
+func (s *sqlSplitter) skipLineComment() {
+	for s.pos < len(s.input) && s.input[s.pos] != '\n' {
+		s.pos++
+	}
+}
+
+func (s *sqlSplitter) skipBlockComment() {
+	s.pos += 2
+	for s.pos < len(s.input) {
+		if s.input[s.pos] == '*' && s.peek(1) == '/' {
+			s.pos += 2
+			break
+		}
+		s.pos++
+	}
+
+	// Mantém os tokens ao redor do comentário separados
+	s.current.WriteRune(' ')
+}
+
+// copyQuoted copia uma string ou identificador entre aspas. A aspa duplicada
+// é o escape padrão do SQL e não encerra o trecho. Nos dialetos com
+// backslashEscapes, \ também escapa o caractere seguinte em strings.
+func (s *sqlSplitter) copyQuoted(open, close rune) {
+	s.current.WriteRune(open)
+	s.pos++
+
+	for s.pos < len(s.input) {
+		r := s.input[s.pos]
+		s.current.WriteRune(r)
+		s.pos++
+
+		if r == '\\' && s.backslashEscapes && (open == '\'' || open == '"') {
+			if s.pos < len(s.input) {
+				s.current.WriteRune(s.input[s.pos])
+				s.pos++
+			}
+			continue
+		}
+
+		if r != close {
+			continue
+		}
+
+		if open == close && s.pos < len(s.input) && s.input[s.pos] == close {
+			s.current.WriteRune(close)
+			s.pos++
+			continue
+		}
+
+		return
+	}
+}
+
+// dollarTag retorna a marca de abertura de uma string com dollar quoting do
+// PostgreSQL ($$ ou $tag$) na posição atual, ou "" se não houver uma.
+func (s *sqlSplitter) dollarTag() string {
+	end := s.pos + 1
+	for end < len(s.input) && (s.input[end] == '_' || unicode.IsLetter(s.input[end])) {
+		end++
+	}
+
+	if end < len(s.input) && s.input[end] == '$' {
+		return string(s.input[s.pos : end+1])
+	}
+
+	return ""
+}
+
+func (s *sqlSplitter) copyDollarQuoted(tag string) {
+	s.current.WriteString(tag)
+	s.pos += len([]rune(tag))
+
+	for s.pos < len(s.input) {
+		if s.input[s.pos] == '$' && strings.HasPrefix(string(s.input[s.pos:]), tag) {
+			s.current.WriteString(tag)
+			s.pos += len([]rune(tag))
+			return
+		}
+
+		s.current.WriteRune(s.input[s.pos])
+		s.pos++
+	}
+}
+
+func (s *sqlSplitter) copyWord() {
+	start := s.pos
+	for s.pos < len(s.input) && isWordPart(s.input[s.pos]) {
+		s.pos++
+	}
+
+	word := string(s.input[start:s.pos])
+	s.current.WriteString(word)
+	s.trackWord(strings.ToUpper(word))
+}
+
+func (s *sqlSplitter) trackWord(word string) {
+	if len(s.leadingWords) < 6 {
+		s.leadingWords = append(s.leadingWords, word)
+
+		if s.leadingWords[0] == "CREATE" && compoundStatementKeywords[word] {
+			s.compound = true
+		}
+	}
+
+	if !s.compound {
+		return
+	}
+
+	switch word {
+	case "CASE":
+		// O CASE de um END CASE fecha o bloco em vez de abrir um novo
+		if s.skipCase {
+			s.skipCase = false
+			return
+		}
+		s.depth++
+	case "BEGIN":
+		s.depth++
+	case "END":
+		next := s.nextWord()
+		if blockEndQualifiers[next] {
+			return
+		}
+		s.skipCase = next == "CASE"
+		if s.depth > 0 {
+			s.depth--
+		}
+	}
+}
+
+// nextWord retorna, em maiúsculas, a próxima palavra após espaços em branco.
+func (s *sqlSplitter) nextWord() string {
+	pos := s.pos
+	for pos < len(s.input) && unicode.IsSpace(s.input[pos]) {
+		pos++
+	}
+
+	start := pos
+	for pos < len(s.input) && isWordPart(s.input[pos]) {
+		pos++
+	}
+
+	return strings.ToUpper(string(s.input[start:pos]))
+}
+
+func (s *sqlSplitter) finishStatement() {
+	statement := strings.TrimSpace(s.current.String())
+	if statement != "" {
+		s.statements = append(s.statements, statement+";")
+	}
+
+	s.current.Reset()
+	s.leadingWords = nil
+	s.compound = false
+	s.depth = 0
+	s.skipCase = false
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isWordPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
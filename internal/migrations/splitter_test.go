@@ -0,0 +1,174 @@
+package migrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		sql     string
+		want    []string
+	}{
+		{
+			name:    "comandos simples",
+			dialect: SQLiteDialect{},
+			sql:     "CREATE TABLE a (id INTEGER);\nDROP TABLE b;",
+			want:    []string{"CREATE TABLE a (id INTEGER);", "DROP TABLE b;"},
+		},
+		{
+			name:    "último comando sem ponto e vírgula",
+			dialect: SQLiteDialect{},
+			sql:     "SELECT 1;\nSELECT 2",
+			want:    []string{"SELECT 1;", "SELECT 2;"},
+		},
+		{
+			name:    "ponto e vírgula dentro de string",
+			dialect: PostgresDialect{},
+			sql:     "INSERT INTO t VALUES ('a; b'); SELECT 5;",
+			want:    []string{"INSERT INTO t VALUES ('a; b');", "SELECT 5;"},
+		},
+		{
+			name:    "aspa duplicada dentro de string",
+			dialect: PostgresDialect{},
+			sql:     "INSERT INTO t VALUES ('it''s; x'); SELECT 5;",
+			want:    []string{"INSERT INTO t VALUES ('it''s; x');", "SELECT 5;"},
+		},
+		{
+			name:    "barra invertida escapa a aspa no MySQL",
+			dialect: MySQLDialect{},
+			sql:     `INSERT INTO t VALUES ('it\'s; x'); SELECT 5;`,
+			want:    []string{`INSERT INTO t VALUES ('it\'s; x');`, "SELECT 5;"},
+		},
+		{
+			name:    "barra invertida escapa aspas duplas no MySQL",
+			dialect: MySQLDialect{},
+			sql:     `INSERT INTO t VALUES ("a\"; b"); SELECT 5;`,
+			want:    []string{`INSERT INTO t VALUES ("a\"; b");`, "SELECT 5;"},
+		},
+		{
+			name:    "barra invertida dupla no fim da string no MySQL",
+			dialect: MySQLDialect{},
+			sql:     `INSERT INTO t VALUES ('c:\\'); SELECT 5;`,
+			want:    []string{`INSERT INTO t VALUES ('c:\\');`, "SELECT 5;"},
+		},
+		{
+			name:    "barra invertida é um caractere comum no PostgreSQL",
+			dialect: PostgresDialect{},
+			sql:     `INSERT INTO t VALUES ('c:\'); SELECT 5;`,
+			want:    []string{`INSERT INTO t VALUES ('c:\');`, "SELECT 5;"},
+		},
+		{
+			name:    "identificadores entre aspas",
+			dialect: PostgresDialect{},
+			sql:     `CREATE TABLE "a;b" ("x""y" TEXT); SELECT 1;`,
+			want:    []string{`CREATE TABLE "a;b" ("x""y" TEXT);`, "SELECT 1;"},
+		},
+		{
+			name:    "identificadores entre crases",
+			dialect: MySQLDialect{},
+			sql:     "CREATE TABLE `a;b` (`c` INT); SELECT 1;",
+			want:    []string{"CREATE TABLE `a;b` (`c` INT);", "SELECT 1;"},
+		},
+		{
+			name:    "identificadores entre colchetes",
+			dialect: SQLiteDialect{},
+			sql:     "CREATE TABLE [a;b] (c INTEGER); SELECT 1;",
+			want:    []string{"CREATE TABLE [a;b] (c INTEGER);", "SELECT 1;"},
+		},
+		{
+			name:    "comentário de linha",
+			dialect: SQLiteDialect{},
+			sql:     "-- remove a tabela; antiga\nDROP TABLE a; -- fim;\nSELECT 1;",
+			want:    []string{"DROP TABLE a;", "SELECT 1;"},
+		},
+		{
+			name:    "comentário de bloco",
+			dialect: SQLiteDialect{},
+			sql:     "SELECT/* ; */1; /* várias\nlinhas; */ SELECT 2;",
+			want:    []string{"SELECT 1;", "SELECT 2;"},
+		},
+		{
+			name:    "marcadores de comentário dentro de string",
+			dialect: SQLiteDialect{},
+			sql:     "INSERT INTO t VALUES ('-- x', '/* y */'); SELECT 1;",
+			want:    []string{"INSERT INTO t VALUES ('-- x', '/* y */');", "SELECT 1;"},
+		},
+		{
+			name:    "trigger com CASE ... END",
+			dialect: SQLiteDialect{},
+			sql: `CREATE TRIGGER t_audit AFTER UPDATE ON t
+BEGIN
+  UPDATE t SET status = CASE WHEN NEW.total > 0 THEN 'pago' ELSE 'aberto' END WHERE id = NEW.id;
+  INSERT INTO log VALUES (NEW.id);
+END;
+SELECT 1;`,
+			want: []string{`CREATE TRIGGER t_audit AFTER UPDATE ON t
+BEGIN
+  UPDATE t SET status = CASE WHEN NEW.total > 0 THEN 'pago' ELSE 'aberto' END WHERE id = NEW.id;
+  INSERT INTO log VALUES (NEW.id);
+END;`, "SELECT 1;"},
+		},
+		{
+			name:    "procedure com END IF e END CASE",
+			dialect: MySQLDialect{},
+			sql: `CREATE PROCEDURE p()
+BEGIN
+  IF 1 THEN SELECT 1; END IF;
+  CASE WHEN 1 THEN SELECT 2; END CASE;
+END;
+SELECT 3;`,
+			want: []string{`CREATE PROCEDURE p()
+BEGIN
+  IF 1 THEN SELECT 1; END IF;
+  CASE WHEN 1 THEN SELECT 2; END CASE;
+END;`, "SELECT 3;"},
+		},
+		{
+			name:    "corpo com $$",
+			dialect: PostgresDialect{},
+			sql: `CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at := now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+SELECT 1;`,
+			want: []string{`CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at := now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;`, "SELECT 1;"},
+		},
+		{
+			name:    "corpo com $tag$",
+			dialect: PostgresDialect{},
+			sql:     "DO $body$ BEGIN PERFORM 1; END $body$; SELECT 1;",
+			want:    []string{"DO $body$ BEGIN PERFORM 1; END $body$;", "SELECT 1;"},
+		},
+		{
+			name:    "string com várias linhas",
+			dialect: SQLiteDialect{},
+			sql:     "INSERT INTO t VALUES ('linha 1;\n-- linha 2\nlinha 3');\nSELECT 1;",
+			want:    []string{"INSERT INTO t VALUES ('linha 1;\n-- linha 2\nlinha 3');", "SELECT 1;"},
+		},
+		{
+			name:    "apenas comentários",
+			dialect: SQLiteDialect{},
+			sql:     "-- nada a fazer\n/* ; */\n",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitSQLStatements(tt.dialect, tt.sql)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitSQLStatements(%q)\n got: %q\nwant: %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
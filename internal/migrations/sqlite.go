@@ -0,0 +1,502 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SQLiteDialect struct{}
+
+func init() {
+	RegisterDialect(SQLiteDialect{})
+}
+
+func (SQLiteDialect) Name() string {
+	return "sqlite"
+}
+
+func (SQLiteDialect) QuoteIdentifier(name string) string {
+	return fmt.Sprintf("\"%s\"", strings.ReplaceAll(name, "\"", "\"\""))
+}
+
+func (SQLiteDialect) SupportsTransactionalDDL() bool {
+	return true
+}
+
+func (SQLiteDialect) SupportsColumnComments() bool {
+	return false
+}
+
+func (SQLiteDialect) SupportsPartialIndexes() bool {
+	return true
+}
+
+func (SQLiteDialect) SupportsBackslashEscapes() bool {
+	return false
+}
+
+func (d SQLiteDialect) CreateTable(model ModelInfo) string {
+	return d.createTable(model, model.TableName)
+}
+
+func (d SQLiteDialect) DropTable(model ModelInfo) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", d.QuoteIdentifier(model.TableName))
+}
+
+func (d SQLiteDialect) CreateIndex(index IndexInfo) string {
+	return createIndexSQL(d, index, true)
+}
+
+func (d SQLiteDialect) DropIndex(index IndexInfo) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s;", d.QuoteIdentifier(index.Name))
+}
+
+func (d SQLiteDialect) ForeignKey(foreignKey ForeignKeyInfo) string {
+	return foreignKeySQL(d, foreignKey)
+}
+
+// AlterTable usa ALTER TABLE quando possível. Alterações que o SQLite não
+// suporta dessa forma (mudança de tipo, nulidade, default, chave primária,
+// constraints ou nova coluna NOT NULL sem default) são feitas recriando a
+// tabela.
+func (d SQLiteDialect) AlterTable(table TableDiff) string {
+	if sqliteNeedsRebuild(table) {
+		return d.rebuildTable(table)
+	}
+
+	var sql strings.Builder
+	tableName := d.QuoteIdentifier(table.Current.TableName)
+
+	for _, index := range table.DroppedIndexes {
+		sql.WriteString(d.DropIndex(index) + "\n")
+	}
+
+	for _, field := range table.DroppedColumns {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", tableName, d.QuoteIdentifier(columnName(field))))
+	}
+
+	for _, field := range table.AddedColumns {
+		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", tableName, d.field(field, true)))
+	}
+
+	for _, index := range table.CreatedIndexes {
+		sql.WriteString(d.CreateIndex(index) + "\n")
+	}
+
+	return sql.String()
+}
+
+func (d SQLiteDialect) createTable(model ModelInfo, tableName string) string {
+	// Com mais de uma chave primária a restrição precisa ser declarada na tabela
+	inlinePrimaryKey := len(primaryKeyColumns(model)) == 1
+
+	return createTableSQL(d, model, func(field FieldInfo) string {
+		return d.field(field, inlinePrimaryKey)
+	}, createTableOptions{tableName: tableName, inlinePrimaryKey: true})
+}
+
+// rebuildTableSuffix é acrescentado ao nome da tabela temporária criada por
+// rebuildTable.
+const rebuildTableSuffix = "__gaver_new"
+
+// rebuildTable segue o procedimento recomendado pelo SQLite para alterações de
+// coluna: cria a nova tabela, copia os dados das colunas em comum, remove a
+// antiga e renomeia a nova. As chaves estrangeiras ficam desativadas durante a
+// troca, para que o DROP TABLE não apague nem invalide as linhas que
+// referenciam a tabela, e são conferidas com foreign_key_check no final. O
+// SQLite ignora PRAGMA foreign_keys dentro de uma transação; o runner o executa
+// antes de abri-la (veja runInTransaction).
+func (d SQLiteDialect) rebuildTable(table TableDiff) string {
+	var sql strings.Builder
+
+	tableName := table.Current.TableName
+	tempName := tableName + rebuildTableSuffix
+
+	sql.WriteString("PRAGMA foreign_keys = OFF;\n")
+	sql.WriteString(d.createTable(table.Current, tempName))
+
+	// Colunas novas NOT NULL sem default recebem o valor zero do tipo nas
+	// linhas existentes, assim como o GORM faria ao preencher o struct
+	var columns, values []string
+	for _, field := range table.Current.Fields {
+		column := d.QuoteIdentifier(columnName(field))
+
+		if _, ok := findField(table.Previous, columnName(field)); ok {
+			columns = append(columns, column)
+			values = append(values, column)
+		} else if field.IsNotNull && field.DefaultValue == "" && !field.IsPrimaryKey {
+			zero, ok := sqliteZeroValue(field)
+			if !ok {
+				// Sem valor zero conhecido a cópia falha se a tabela tiver linhas
+				sql.WriteString(fmt.Sprintf("-- Column %s is NOT NULL without a default and has no known zero value: set a default in the model if %s has rows\n", columnName(field), tableName))
+				continue
+			}
+
+			columns = append(columns, column)
+			values = append(values, zero)
+		}
+	}
+
+	if len(columns) > 0 {
+		sql.WriteString(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;\n", d.QuoteIdentifier(tempName), strings.Join(columns, ", "), strings.Join(values, ", "), d.QuoteIdentifier(tableName)))
+	}
+
+	sql.WriteString(fmt.Sprintf("DROP TABLE %s;\n", d.QuoteIdentifier(tableName)))
+	sql.WriteString(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", d.QuoteIdentifier(tempName), d.QuoteIdentifier(tableName)))
+
+	for _, index := range modelIndexes(table.Current) {
+		sql.WriteString(d.CreateIndex(index) + "\n")
+	}
+
+	sql.WriteString(fmt.Sprintf("PRAGMA foreign_key_check(%s);\n", d.QuoteIdentifier(tableName)))
+	sql.WriteString("PRAGMA foreign_keys = ON;\n")
+
+	return sql.String()
+}
+
+// sqliteNeedsRebuild indica se a alteração não pode ser expressa com
+// ADD/DROP COLUMN e exige recriar a tabela. O SQLite também não permite
+// adicionar ou remover constraints CHECK e FOREIGN KEY de uma tabela existente.
+func sqliteNeedsRebuild(table TableDiff) bool {
+	if len(table.ChangedColumns) > 0 || len(table.CreatedChecks) > 0 || len(table.DroppedChecks) > 0 ||
+		len(table.CreatedForeignKeys) > 0 || len(table.DroppedForeignKeys) > 0 {
+		return true
+	}
+
+	// O SQLite não aceita ADD COLUMN NOT NULL sem default. Um default provisório
+	// ficaria no banco e no SQL das migrações, diferente do model
+	for _, field := range table.AddedColumns {
+		if field.IsPrimaryKey || (field.IsNotNull && field.DefaultValue == "") {
+			return true
+		}
+	}
+
+	for _, field := range table.DroppedColumns {
+		if field.IsPrimaryKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d SQLiteDialect) field(field FieldInfo, inlinePrimaryKey bool) string {
+	var parts []string
+	parts = append(parts, d.QuoteIdentifier(columnName(field)))
+	parts = append(parts, d.ColumnType(field))
+
+	if field.IsPrimaryKey && inlinePrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+
+		// AUTOINCREMENT só é aceito em INTEGER PRIMARY KEY
+		if field.AutoIncrement {
+			parts = append(parts, "AUTOINCREMENT")
+		}
+	}
+
+	if field.IsNotNull && !field.IsPrimaryKey {
+		parts = append(parts, "NOT NULL")
+	}
+
+	if field.DefaultValue != "" && !field.IsPrimaryKey {
+		parts = append(parts, fmt.Sprintf("DEFAULT %s", field.DefaultValue))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// sqliteZeroValue retorna o valor que o GORM gravaria para o valor zero do
+// tipo Go do campo. Tipos sem valor zero conhecido (JSON, tipos próprios)
+// retornam false.
+func sqliteZeroValue(field FieldInfo) (string, bool) {
+	switch field.Type {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "bool", "boolean":
+		return "0", true
+	case "string":
+		return "''", true
+	case "time.Time":
+		return "'0001-01-01 00:00:00+00:00'", true
+	case "uuid.UUID":
+		return "'00000000-0000-0000-0000-000000000000'", true
+	case "[]byte":
+		return "X''", true
+	default:
+		return "", false
+	}
+}
+
+func (SQLiteDialect) ColumnType(field FieldInfo) string {
+	if field.SQLType != "" {
+		return field.SQLType
+	}
+
+	switch field.Type {
+	case "string":
+		return "TEXT"
+	case "int", "int8", "int16", "int32", "int64":
+		return "INTEGER"
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return "INTEGER"
+	case "float32", "float64":
+		return "REAL"
+	case "bool", "boolean":
+		return "INTEGER"
+	case "time.Time":
+		return "TEXT"
+	case "uuid.UUID":
+		return "TEXT"
+	case "gorm.DeletedAt":
+		return "TEXT"
+	default:
+		return "TEXT"
+	}
+}
+
+// InspectSchema lê as tabelas pelo sqlite_master e pelos PRAGMAs table_info,
+// index_list, index_xinfo e foreign_key_list. Os nomes das chaves estrangeiras
+// e as checks, que o SQLite não expõe, vêm do CREATE TABLE guardado no banco.
+func (d SQLiteDialect) InspectSchema(db *gorm.DB) ([]ModelInfo, error) {
+	var tables []struct {
+		Name string
+		SQL  string `gorm:"column:sql"`
+	}
+	if err := db.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name").Scan(&tables).Error; err != nil {
+		return nil, fmt.Errorf("erro ao listar tabelas: %w", err)
+	}
+
+	var models []ModelInfo
+	for _, table := range tables {
+		if isInternalTable(table.Name) {
+			continue
+		}
+
+		model, err := d.inspectTable(db, table.Name, table.SQL)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler tabela %s: %w", table.Name, err)
+		}
+
+		models = append(models, model)
+	}
+
+	return inspectedModels(models), nil
+}
+
+func (d SQLiteDialect) inspectTable(db *gorm.DB, tableName, createSQL string) (ModelInfo, error) {
+	model := ModelInfo{Name: tableName, TableName: tableName}
+
+	// O CREATE TABLE original é usado apenas para o que os PRAGMAs não informam
+	parsed := newSchemaState()
+	if err := parsed.apply(createSQL); err != nil {
+		return model, err
+	}
+	declared := parsed.tables[strings.ToLower(tableName)]
+	if declared != nil {
+		model.Checks = declared.Checks
+	}
+
+	var columns []struct {
+		Name      string
+		Type      string
+		NotNull   bool    `gorm:"column:notnull"`
+		DfltValue *string `gorm:"column:dflt_value"`
+		Pk        int
+	}
+	if err := db.Raw(fmt.Sprintf("PRAGMA table_info(%s)", d.QuoteIdentifier(tableName))).Scan(&columns).Error; err != nil {
+		return model, err
+	}
+
+	primaryKeys := 0
+	for _, column := range columns {
+		if column.Pk > 0 {
+			primaryKeys++
+		}
+	}
+
+	for _, column := range columns {
+		field := FieldInfo{
+			Name:         column.Name,
+			Column:       column.Name,
+			SQLType:      column.Type,
+			IsPrimaryKey: column.Pk > 0,
+			IsNotNull:    column.NotNull,
+		}
+
+		if column.DfltValue != nil {
+			field.DefaultValue = *column.DfltValue
+		}
+
+		// AUTOINCREMENT só existe em INTEGER PRIMARY KEY, então basta saber se
+		// a tabela o declara
+		if field.IsPrimaryKey && primaryKeys == 1 && strings.Contains(strings.ToUpper(createSQL), "AUTOINCREMENT") {
+			field.AutoIncrement = true
+		}
+
+		model.Fields = append(model.Fields, field)
+	}
+
+	indexes, err := d.inspectIndexes(db, tableName)
+	if err != nil {
+		return model, err
+	}
+	model.Indexes = indexes
+
+	foreignKeys, err := d.inspectForeignKeys(db, tableName, declared)
+	if err != nil {
+		return model, err
+	}
+	model.ForeignKeys = foreignKeys
+
+	return model, nil
+}
+
+// inspectIndexes ignora os índices criados pelo próprio SQLite para chaves
+// primárias e constraints UNIQUE, mantendo apenas os de CREATE INDEX.
+func (d SQLiteDialect) inspectIndexes(db *gorm.DB, tableName string) ([]IndexInfo, error) {
+	var list []struct {
+		Name    string
+		Unique  bool
+		Origin  string
+		Partial bool
+	}
+	if err := db.Raw(fmt.Sprintf("PRAGMA index_list(%s)", d.QuoteIdentifier(tableName))).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+
+	var indexes []IndexInfo
+	for _, item := range list {
+		if item.Origin != "c" {
+			continue
+		}
+
+		var columns []struct {
+			Name *string
+			Desc bool
+			Key  bool
+		}
+		if err := db.Raw(fmt.Sprintf("PRAGMA index_xinfo(%s)", d.QuoteIdentifier(item.Name))).Scan(&columns).Error; err != nil {
+			return nil, err
+		}
+
+		index := IndexInfo{Name: item.Name, Table: tableName, Unique: item.Unique}
+		sorted, expression := false, false
+		for _, column := range columns {
+			if !column.Key {
+				continue
+			}
+			if column.Name == nil {
+				expression = true
+				break
+			}
+
+			order := ""
+			if column.Desc {
+				order = "DESC"
+				sorted = true
+			}
+
+			index.Columns = append(index.Columns, *column.Name)
+			index.Sorts = append(index.Sorts, order)
+		}
+
+		// Índices sobre expressões não têm representação nos models
+		if expression {
+			continue
+		}
+
+		if !sorted {
+			index.Sorts = nil
+		}
+
+		if item.Partial {
+			var createSQL string
+			if err := db.Raw("SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?", item.Name).Scan(&createSQL).Error; err != nil {
+				return nil, err
+			}
+			if parsed, ok := parseCreateIndex(createSQL); ok {
+				index.Where = parsed.Where
+			}
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+func (d SQLiteDialect) inspectForeignKeys(db *gorm.DB, tableName string, declared *ModelInfo) ([]ForeignKeyInfo, error) {
+	var rows []struct {
+		ID       int `gorm:"column:id"`
+		Table    string
+		From     string
+		To       string
+		OnUpdate string `gorm:"column:on_update"`
+		OnDelete string `gorm:"column:on_delete"`
+	}
+	if err := db.Raw(fmt.Sprintf("PRAGMA foreign_key_list(%s)", d.QuoteIdentifier(tableName))).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var foreignKeys []ForeignKeyInfo
+	byID := make(map[int]int)
+	for _, row := range rows {
+		position, ok := byID[row.ID]
+		if !ok {
+			foreignKeys = append(foreignKeys, ForeignKeyInfo{
+				RefTable: row.Table,
+				OnDelete: inspectedAction(row.OnDelete),
+				OnUpdate: inspectedAction(row.OnUpdate),
+			})
+			position = len(foreignKeys) - 1
+			byID[row.ID] = position
+		}
+
+		foreignKeys[position].Columns = append(foreignKeys[position].Columns, row.From)
+		foreignKeys[position].RefColumns = append(foreignKeys[position].RefColumns, row.To)
+	}
+
+	for i := range foreignKeys {
+		foreignKeys[i].Name = fmt.Sprintf("fk_%s_%s", tableName, strings.Join(foreignKeys[i].Columns, "_"))
+
+		if declared == nil {
+			continue
+		}
+		for _, candidate := range declared.ForeignKeys {
+			if strings.EqualFold(candidate.RefTable, foreignKeys[i].RefTable) && sameColumns(candidate.Columns, foreignKeys[i].Columns) {
+				foreignKeys[i].Name = candidate.Name
+				break
+			}
+		}
+	}
+
+	return foreignKeys, nil
+}
+
+// AcquireLock usa a linha de gaver_migrations_lock como bloqueio, pois o
+// SQLite não tem advisory locks. Se uma execução for interrompida, a linha
+// permanece e precisa ser removida manualmente.
+func (SQLiteDialect) AcquireLock(db *gorm.DB, holder string, timeout time.Duration) (func() error, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&MigrationLock{ID: 1, Holder: holder, LockedAt: time.Now()})
+		if result.Error != nil {
+			return nil, fmt.Errorf("erro ao obter bloqueio de migrações: %w", result.Error)
+		}
+
+		if result.RowsAffected > 0 {
+			return func() error { return clearLockHolder(db) }, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w\nSe essa execução foi interrompida, remova o bloqueio com: DELETE FROM %s;", ErrLockTimeout, LockTable)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,392 @@
+package migrations
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReplacesMarker indica, no cabeçalho de um squash, cada migração original
+// que ele substitui.
+const ReplacesMarker = "-- +replaces"
+
+// Os arquivos gerados pelo squashmigrations se chamam <de>_squashed_<até>.sql
+const squashedNamePrefix = "squashed_"
+
+var (
+	replacedMigrationPattern = regexp.MustCompile(`^(\d{4})_(.+)$`)
+
+	// Comandos que o squash reproduz a partir do schema resultante. Os demais
+	// (dados, views, triggers...) se perdem e geram um aviso.
+	squashableStatementPattern = regexp.MustCompile(`(?is)^((CREATE\s+(UNIQUE\s+)?INDEX|CREATE\s+(TABLE|SEQUENCE)|ALTER\s+TABLE|DROP\s+(TABLE|INDEX|SEQUENCE)|COMMENT\s+ON\s+COLUMN)\s|SELECT\s+setval\s*\(|PRAGMA\s+foreign_key)`)
+)
+
+// ReplacedMigration identifica uma migração original substituída por um
+// squash. É com esse número e nome que o squash fica registrado em
+// gaver_migrations, para que bancos antigos e novos tenham o mesmo histórico.
+type ReplacedMigration struct {
+	Number int
+	Name   string
+}
+
+func (m ReplacedMigration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Number, m.Name)
+}
+
+// SquashedMigration é o resultado de BuildSquashedMigration, ainda não gravado
+// em migrations/.
+type SquashedMigration struct {
+	File    MigrationFile
+	Content string
+	Models  []ModelInfo
+}
+
+// BuildSquashedMigration junta as migrações de from a to em uma só, com o SQL
+// que leva o schema anterior a from direto ao schema resultante de to. Retorna
+// também avisos sobre comandos das migrações originais que não fazem parte do
+// squash.
+func BuildSquashedMigration(dialect Dialect, files []MigrationFile, from, to int) (*SquashedMigration, []string, error) {
+	if from >= to {
+		return nil, nil, fmt.Errorf("intervalo inválido: %04d a %04d", from, to)
+	}
+
+	for _, number := range []int{from, to} {
+		if _, err := FindMigrationFile(files, number); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var replaced []MigrationFile
+	for _, file := range files {
+		if file.Number < from || file.Number > to {
+			continue
+		}
+
+		if file.Go != nil {
+			return nil, nil, fmt.Errorf("a migração %s é escrita em Go e não pode fazer parte de um squash", file.FullName)
+		}
+
+		if len(file.Replaces) > 0 {
+			return nil, nil, fmt.Errorf("a migração %s já é um squash; remova os arquivos originais dela e escolha um intervalo que não a inclua", file.FullName)
+		}
+
+		replaced = append(replaced, file)
+	}
+
+	previous, err := loadSchema(dialect, files, func(file MigrationFile) bool { return file.Number < from })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := loadSchema(dialect, files, func(file MigrationFile) bool { return file.Number <= to })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []string
+	var replaces []ReplacedMigration
+	for _, file := range replaced {
+		replaces = append(replaces, ReplacedMigration{Number: file.Number, Name: file.Name})
+
+		up, _, err := ReadMigrationStatements(dialect, file.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, statement := range up {
+			if !squashableStatement(statement) {
+				warnings = append(warnings, fmt.Sprintf("%s: comando não incluído no squash: %s", file.FullName, firstLine(statement)))
+			}
+		}
+	}
+
+	upSQL := GenerateSQL(dialect, DiffModels(previous, current, dialect))
+	downSQL := GenerateSQL(dialect, DiffModels(current, previous, dialect))
+
+	name := fmt.Sprintf("%s%04d", squashedNamePrefix, to)
+	fullName := fmt.Sprintf("%04d_%s.sql", from, name)
+
+	return &SquashedMigration{
+		File: MigrationFile{
+			Path:     filepath.Join(filepath.Dir(replaced[0].Path), fullName),
+			Number:   from,
+			Name:     name,
+			FullName: fullName,
+			Replaces: replaces,
+		},
+		Content: FormatSquashedMigration(replaces, upSQL, downSQL),
+		Models:  current,
+	}, warnings, nil
+}
+
+// FormatSquashedMigration monta o conteúdo de um squash: o cabeçalho com as
+// migrações substituídas seguido das seções de avanço e de reversão.
+func FormatSquashedMigration(replaces []ReplacedMigration, up, down string) string {
+	var content strings.Builder
+
+	for _, migration := range replaces {
+		content.WriteString(fmt.Sprintf("%s %s\n", ReplacesMarker, migration))
+	}
+
+	content.WriteString(FormatMigration(up, down))
+
+	return content.String()
+}
+
+func squashableStatement(statement string) bool {
+	statement = strings.TrimSpace(statement)
+
+	// Cópia de dados feita pelo SQLite ao recriar uma tabela
+	if strings.Contains(statement, rebuildTableSuffix) {
+		return true
+	}
+
+	return squashableStatementPattern.MatchString(statement)
+}
+
+func firstLine(statement string) string {
+	line, _, cut := strings.Cut(strings.TrimSpace(statement), "\n")
+	if cut {
+		return line + " ..."
+	}
+	return line
+}
+
+// readReplaces lê o cabeçalho -- +replaces de um squash.
+func readReplaces(filePath string) ([]ReplacedMigration, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de migração: %w", err)
+	}
+	defer file.Close()
+
+	var replaces []ReplacedMigration
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, ReplacesMarker) {
+			continue
+		}
+
+		matches := replacedMigrationPattern.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(line, ReplacesMarker)))
+		if matches == nil {
+			return nil, fmt.Errorf("linha %q inválida em %s", line, filepath.Base(filePath))
+		}
+
+		number, _ := strconv.Atoi(matches[1])
+		replaces = append(replaces, ReplacedMigration{Number: number, Name: matches[2]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de migração: %w", err)
+	}
+
+	return replaces, nil
+}
+
+// collapseSquashedMigrations retira da lista os arquivos originais substituídos
+// por um squash, guardando-os em Replaced do próprio squash.
+func collapseSquashedMigrations(files []MigrationFile) []MigrationFile {
+	replacedBy := make(map[ReplacedMigration]int)
+	for i, file := range files {
+		for _, migration := range file.Replaces {
+			replacedBy[migration] = i
+		}
+	}
+
+	if len(replacedBy) == 0 {
+		return files
+	}
+
+	isReplaced := make(map[int]bool)
+	for i, file := range files {
+		if len(file.Replaces) > 0 || file.Go != nil {
+			continue
+		}
+
+		if squash, ok := replacedBy[ReplacedMigration{Number: file.Number, Name: file.Name}]; ok {
+			files[squash].Replaced = append(files[squash].Replaced, file)
+			isReplaced[i] = true
+		}
+	}
+
+	var collapsed []MigrationFile
+	for i, file := range files {
+		if !isReplaced[i] {
+			collapsed = append(collapsed, file)
+		}
+	}
+
+	return collapsed
+}
+
+// coveredMigrations retorna os registros de gaver_migrations que correspondem
+// ao arquivo: as migrações substituídas, no caso de um squash, ou o próprio
+// arquivo.
+func coveredMigrations(file MigrationFile) []ReplacedMigration {
+	if len(file.Replaces) > 0 {
+		return file.Replaces
+	}
+
+	return []ReplacedMigration{{Number: file.Number, Name: file.Name}}
+}
+
+// migrationApplied indica se o arquivo está aplicado. Um squash só está
+// aplicado quando todas as migrações que ele substitui estão registradas.
+func migrationApplied(file MigrationFile, appliedNumbers map[int]bool) bool {
+	for _, migration := range coveredMigrations(file) {
+		if !appliedNumbers[migration.Number] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replacedChecksumMatches confere o registro de uma migração substituída por
+// um squash que não corresponde ao próprio squash: o banco pode tê-la aplicado
+// pelo arquivo original.
+func replacedChecksumMatches(file MigrationFile, migration AppliedMigration) (bool, error) {
+	for _, original := range file.Replaced {
+		if original.Number == migration.Number {
+			checksum, err := FileChecksum(original.Path)
+			if err != nil {
+				return false, err
+			}
+			return checksum == migration.Checksum, nil
+		}
+	}
+
+	// O arquivo original foi removido depois do squash; não há o que conferir
+	return true, nil
+}
+
+func appliedReplacedNumbers(db *gorm.DB, migrationFile MigrationFile) (map[int]bool, int, error) {
+	applied, err := ListAppliedMigrations(db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	replaced := make(map[int]bool)
+	for _, migration := range migrationFile.Replaces {
+		replaced[migration.Number] = true
+	}
+
+	appliedNumbers := make(map[int]bool)
+	count := 0
+	for _, migration := range applied {
+		appliedNumbers[migration.Number] = true
+		if replaced[migration.Number] {
+			count++
+		}
+	}
+
+	return appliedNumbers, count, nil
+}
+
+func findReplacedFile(migrationFile MigrationFile, migration ReplacedMigration) (MigrationFile, error) {
+	for _, original := range migrationFile.Replaced {
+		if original.Number == migration.Number {
+			return original, nil
+		}
+	}
+
+	return MigrationFile{}, fmt.Errorf("o banco aplicou apenas parte das migrações substituídas por %s, mas o arquivo %s não existe mais; restaure-o para continuar", migrationFile.FullName, migration)
+}
+
+// applySquashedMigration executa o squash em bancos que não aplicaram
+// nenhuma das migrações originais, registrando todas elas. Quando o banco já
+// aplicou parte delas, executa apenas os arquivos originais restantes.
+func applySquashedMigration(db *gorm.DB, dialect Dialect, migrationFile MigrationFile, up, checksum string, transactional bool) error {
+	appliedNumbers, count, err := appliedReplacedNumbers(db, migrationFile)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		for _, migration := range migrationFile.Replaces {
+			if appliedNumbers[migration.Number] {
+				continue
+			}
+
+			original, err := findReplacedFile(migrationFile, migration)
+			if err != nil {
+				return err
+			}
+
+			if err := ApplyMigration(db, dialect, original); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	startedAt := time.Now()
+
+	return runInTransaction(db, dialect, up, transactional, func(tx *gorm.DB) error {
+		if err := execStatements(tx, dialect, migrationFile, up, transactional); err != nil {
+			return err
+		}
+
+		for _, migration := range migrationFile.Replaces {
+			record := MigrationFile{Number: migration.Number, Name: migration.Name, FullName: migration.String()}
+			if err := RecordMigration(tx, record, checksum, time.Since(startedAt)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// revertSquashedMigration executa a seção down do squash quando todas as
+// migrações substituídas estão aplicadas. Se apenas parte delas estiver,
+// reverte os arquivos originais aplicados, do mais recente ao mais antigo.
+func revertSquashedMigration(db *gorm.DB, dialect Dialect, migrationFile MigrationFile, down string, transactional bool) error {
+	appliedNumbers, count, err := appliedReplacedNumbers(db, migrationFile)
+	if err != nil {
+		return err
+	}
+
+	if count < len(migrationFile.Replaces) {
+		for i := len(migrationFile.Replaces) - 1; i >= 0; i-- {
+			migration := migrationFile.Replaces[i]
+			if !appliedNumbers[migration.Number] {
+				continue
+			}
+
+			original, err := findReplacedFile(migrationFile, migration)
+			if err != nil {
+				return err
+			}
+
+			if err := RevertMigration(db, dialect, original); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return runInTransaction(db, dialect, down, transactional, func(tx *gorm.DB) error {
+		if err := execStatements(tx, dialect, migrationFile, down, transactional); err != nil {
+			return err
+		}
+
+		for _, migration := range migrationFile.Replaces {
+			if err := RemoveMigrationRecord(tx, migration.Number); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,263 @@
+package migrations
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// squashSteps são os schemas das três migrações originais usadas nos testes.
+func squashSteps() [][]ModelInfo {
+	withPhone := goldenUsers()
+	withPhone.Fields = append(withPhone.Fields, FieldInfo{Name: "Phone", Column: "phone", Type: "string", Size: 20})
+
+	return [][]ModelInfo{
+		{goldenUsers()},
+		{goldenUsers(), goldenOrders()},
+		{withPhone, goldenOrders()},
+	}
+}
+
+// writeSquashOriginals grava as migrações 0001 a 0003 em dir. A 0002 tem
+// também um INSERT, que o squash não reproduz.
+func writeSquashOriginals(t *testing.T, dialect Dialect, dir string) {
+	t.Helper()
+
+	var previous []ModelInfo
+	for i, current := range squashSteps() {
+		up := GenerateSQL(dialect, DiffModels(previous, current, dialect))
+		down := GenerateSQL(dialect, DiffModels(current, previous, dialect))
+		if i == 1 {
+			up += "INSERT INTO users (name, email) VALUES ('admin', 'admin@example.com');\n"
+		}
+		writeMigration(t, dir, i+1, []string{"initial", "orders", "user_phone"}[i], FormatMigration(up, down))
+		previous = current
+	}
+}
+
+// writeSquash grava o squash de 0001 a 0003 e retorna os arquivos listados.
+func writeSquash(t *testing.T, dialect Dialect, dir string) []MigrationFile {
+	t.Helper()
+
+	files, err := ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	squashed, _, err := BuildSquashedMigration(dialect, files, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(squashed.File.Path, []byte(squashed.Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err = ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func appliedNumbersAndChecksums(t *testing.T, db *gorm.DB) map[int]string {
+	t.Helper()
+
+	applied, err := ListAppliedMigrations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checksums := make(map[int]string)
+	for _, migration := range applied {
+		checksums[migration.Number] = migration.Checksum
+	}
+	return checksums
+}
+
+func TestBuildSquashedMigration(t *testing.T) {
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+	writeSquashOriginals(t, dialect, dir)
+
+	files, err := ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	squashed, warnings, err := BuildSquashedMigration(dialect, files, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if squashed.File.FullName != "0001_squashed_0003.sql" {
+		t.Errorf("nome = %s, esperado 0001_squashed_0003.sql", squashed.File.FullName)
+	}
+
+	wantReplaces := []ReplacedMigration{{1, "initial"}, {2, "orders"}, {3, "user_phone"}}
+	if !reflect.DeepEqual(squashed.File.Replaces, wantReplaces) {
+		t.Errorf("replaces = %v, esperado %v", squashed.File.Replaces, wantReplaces)
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "0002_orders.sql") || !strings.Contains(warnings[0], "INSERT INTO users") {
+		t.Errorf("avisos = %v, esperado apenas o INSERT da 0002", warnings)
+	}
+
+	// O squash leva do schema vazio direto ao schema final
+	if diff := DiffModels(squashed.Models, squashSteps()[2], dialect); !diff.IsEmpty() {
+		t.Errorf("schema do squash difere do final: %v", DescribeDiff(diff))
+	}
+	up, down := ParseMigrationSections(squashed.Content)
+	if strings.Contains(up, "ALTER TABLE") {
+		t.Errorf("o squash não deveria repetir os passos intermediários:\n%s", up)
+	}
+	if !strings.Contains(down, "DROP TABLE") {
+		t.Errorf("a seção down deveria remover as tabelas:\n%s", down)
+	}
+
+	for _, tt := range []struct{ from, to int }{{3, 1}, {2, 2}, {1, 9}} {
+		if _, _, err := BuildSquashedMigration(dialect, files, tt.from, tt.to); err == nil {
+			t.Errorf("intervalo %d a %d deveria ser recusado", tt.from, tt.to)
+		}
+	}
+}
+
+func TestReadReplaces(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := writeMigration(t, dir, 1, "squashed_0002", ReplacesMarker+" 0001_initial\n"+ReplacesMarker+" 0002_orders\n-- +up\nSELECT 1;\n")
+	replaces, err := readReplaces(valid.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ReplacedMigration{{1, "initial"}, {2, "orders"}}; !reflect.DeepEqual(replaces, want) {
+		t.Errorf("replaces = %v, esperado %v", replaces, want)
+	}
+
+	invalid := writeMigration(t, dir, 3, "squashed_0004", ReplacesMarker+" initial\n-- +up\nSELECT 1;\n")
+	if _, err := readReplaces(invalid.Path); err == nil {
+		t.Error("linha -- +replaces sem número deveria ser recusada")
+	}
+}
+
+func TestCollapseSquashedMigrations(t *testing.T) {
+	files := []MigrationFile{
+		{Number: 1, Name: "initial"},
+		{Number: 1, Name: "squashed_0002", Replaces: []ReplacedMigration{{1, "initial"}, {2, "orders"}}},
+		{Number: 2, Name: "orders"},
+		// Mesmo número, outro nome: não é um dos arquivos substituídos
+		{Number: 2, Name: "payments"},
+		{Number: 3, Name: "user_phone"},
+	}
+
+	collapsed := collapseSquashedMigrations(files)
+
+	var names []string
+	for _, file := range collapsed {
+		names = append(names, file.Name)
+	}
+	if want := []string{"squashed_0002", "payments", "user_phone"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("arquivos = %v, esperado %v", names, want)
+	}
+
+	var replaced []string
+	for _, file := range collapsed[0].Replaced {
+		replaced = append(replaced, file.Name)
+	}
+	if want := []string{"initial", "orders"}; !reflect.DeepEqual(replaced, want) {
+		t.Errorf("Replaced = %v, esperado %v", replaced, want)
+	}
+}
+
+// Em um banco novo o squash roda inteiro e registra todas as migrações
+// substituídas; a reversão remove todos os registros.
+func TestApplySquashedMigration(t *testing.T) {
+	db := openSQLite(t)
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+	writeSquashOriginals(t, dialect, dir)
+	files := writeSquash(t, dialect, dir)
+
+	if len(files) != 1 || len(files[0].Replaced) != 3 {
+		t.Fatalf("arquivos = %+v, esperado apenas o squash com os três originais", files)
+	}
+	squash := files[0]
+
+	if err := ApplyMigration(db, dialect, squash); err != nil {
+		t.Fatal(err)
+	}
+
+	checksum, err := MigrationChecksum(squash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{1: checksum, 2: checksum, 3: checksum}
+	if got := appliedNumbersAndChecksums(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("registros = %v, esperado %v", got, want)
+	}
+	assertDatabaseSchema(t, dialect, db, squashSteps()[2])
+
+	// O INSERT da 0002 não faz parte do squash
+	if count := countRows(t, db, "users"); count != 0 {
+		t.Errorf("users tem %d linhas, esperado 0", count)
+	}
+
+	if err := RevertMigration(db, dialect, squash); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedNumbersAndChecksums(t, db); len(got) != 0 {
+		t.Errorf("registros após reverter = %v, esperado nenhum", got)
+	}
+	assertDatabaseSchema(t, dialect, db, nil)
+}
+
+// Um banco que já aplicou parte dos originais executa apenas os restantes,
+// pelos próprios arquivos.
+func TestApplySquashedMigrationPartial(t *testing.T) {
+	db := openSQLite(t)
+	dialect := SQLiteDialect{}
+	dir := t.TempDir()
+	writeSquashOriginals(t, dialect, dir)
+
+	originals, err := ListMigrationFiles(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyMigration(db, dialect, originals[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	files := writeSquash(t, dialect, dir)
+	if err := ApplyMigration(db, dialect, files[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := make(map[int]string)
+	for _, original := range originals {
+		checksum, err := MigrationChecksum(original)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[original.Number] = checksum
+	}
+	if got := appliedNumbersAndChecksums(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("registros = %v, esperado os checksums dos originais %v", got, want)
+	}
+	assertDatabaseSchema(t, dialect, db, squashSteps()[2])
+
+	// O INSERT da 0002 rodou com o arquivo original
+	if count := countRows(t, db, "users"); count != 1 {
+		t.Errorf("users tem %d linhas, esperado 1", count)
+	}
+
+	// Com tudo aplicado, a reversão usa a seção down do squash
+	if err := RevertMigration(db, dialect, files[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedNumbersAndChecksums(t, db); len(got) != 0 {
+		t.Errorf("registros após reverter = %v, esperado nenhum", got)
+	}
+}
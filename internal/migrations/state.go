@@ -0,0 +1,867 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	createTablePattern       = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)\s*\((.*)\)[^)]*$`)
+	dropTablePattern         = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?([^\s;]+)\s*;?$`)
+	renameTablePattern       = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+RENAME\s+TO\s+([^\s;]+)\s*;?$`)
+	addColumnPattern         = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+ADD\s+(?:COLUMN\s+)?(.+?)\s*;?$`)
+	dropColumnPattern        = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?([^\s;]+)\s*;?$`)
+	alterColumnPattern       = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+ALTER\s+(?:COLUMN\s+)?([^\s]+)\s+(.+?)\s*;?$`)
+	addPrimaryPattern        = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+ADD\s+PRIMARY\s+KEY\s*\((.*)\)\s*;?$`)
+	constraintPattern        = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+(ADD|DROP)\s+(?:CONSTRAINT|FOREIGN\s+KEY)\s+(?:IF\s+EXISTS\s+)?([^\s;(]+)`)
+	createIndexPattern       = regexp.MustCompile(`(?is)^CREATE\s+(UNIQUE\s+)?INDEX\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s]+)\s+ON\s+([^\s(]+)\s*\((.*?)\)\s*(?:WHERE\s+(.+?))?\s*;?$`)
+	dropIndexPattern         = regexp.MustCompile(`(?is)^DROP\s+INDEX\s+(?:IF\s+EXISTS\s+)?([^\s;]+)(?:\s+ON\s+[^\s;]+)?\s*;?$`)
+	modifyColumnPattern      = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+MODIFY\s+(?:COLUMN\s+)?(.+?)\s*;?$`)
+	dropPrimaryPattern       = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+DROP\s+PRIMARY\s+KEY\s*;?$`)
+	checkPattern             = regexp.MustCompile(`(?is)^CONSTRAINT\s+([^\s]+)\s+CHECK\s*\((.*)\)$`)
+	addCheckPattern          = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+ADD\s+(CONSTRAINT\s+[^\s]+\s+CHECK\s*\(.*\))\s*;?$`)
+	dropConstraintPattern    = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+DROP\s+(?:CONSTRAINT|CHECK|FOREIGN\s+KEY)\s+(?:IF\s+EXISTS\s+)?([^\s;]+)\s*;?$`)
+	foreignKeyPattern        = regexp.MustCompile(`(?is)^CONSTRAINT\s+([^\s]+)\s+FOREIGN\s+KEY\s*\(([^)]*)\)\s*REFERENCES\s+([^\s(]+)\s*\(([^)]*)\)(.*)$`)
+	addForeignKeyPattern     = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([^\s]+)\s+ADD\s+(CONSTRAINT\s+[^\s]+\s+FOREIGN\s+KEY\s*\(.*)\s*;?$`)
+	referentialActionPattern = regexp.MustCompile(`(?i)ON\s+(DELETE|UPDATE)\s+(SET\s+NULL|SET\s+DEFAULT|NO\s+ACTION|CASCADE|RESTRICT)`)
+	commentPattern           = regexp.MustCompile(`(?is)^COMMENT\s+ON\s+COLUMN\s+([^\s.]+)\.([^\s]+)\s+IS\s+(.+?)\s*;?$`)
+)
+
+// Palavras que encerram o tipo de uma coluna na definição de uma tabela
+var columnConstraintKeywords = map[string]bool{
+	"PRIMARY":        true,
+	"NOT":            true,
+	"NULL":           true,
+	"DEFAULT":        true,
+	"UNIQUE":         true,
+	"CHECK":          true,
+	"REFERENCES":     true,
+	"COLLATE":        true,
+	"CONSTRAINT":     true,
+	"AUTOINCREMENT":  true,
+	"AUTO_INCREMENT": true,
+	"COMMENT":        true,
+	"GENERATED":      true,
+}
+
+// LoadSchemaFromMigrations reconstrói o schema resultante das migrações já
+// geradas. O snapshot mais recente é usado como base e apenas os arquivos
+// posteriores a ele (ou todos, se não houver snapshot) têm seus comandos DDL
+// reexecutados em memória.
+func LoadSchemaFromMigrations(dialect Dialect, migrationsDir string) ([]ModelInfo, error) {
+	files, err := ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadSchema(dialect, files, func(MigrationFile) bool { return true })
+}
+
+// LoadAppliedSchema reconstrói o schema considerando apenas as migrações já
+// aplicadas no banco, ou seja, o schema que o banco deveria ter.
+func LoadAppliedSchema(dialect Dialect, migrationsDir string, applied []AppliedMigration) ([]ModelInfo, error) {
+	files, err := ListMigrationFiles(migrationsDir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedNumbers := make(map[int]bool)
+	for _, migration := range applied {
+		appliedNumbers[migration.Number] = true
+	}
+
+	return loadSchema(dialect, files, func(file MigrationFile) bool {
+		return migrationApplied(file, appliedNumbers)
+	})
+}
+
+// loadSchema reexecuta, em ordem, os arquivos aceitos por include. Um
+// snapshot só é usado como base quando todos os arquivos até ele são aceitos.
+func loadSchema(dialect Dialect, files []MigrationFile, include func(MigrationFile) bool) ([]ModelInfo, error) {
+	prefix := 0
+	for prefix < len(files) && include(files[prefix]) {
+		prefix++
+	}
+
+	state := newSchemaState()
+	start := 0
+
+	for i := prefix - 1; i >= 0; i-- {
+		if files[i].Go != nil {
+			continue
+		}
+
+		snapshotPath := SnapshotPath(files[i].Path)
+		if _, err := os.Stat(snapshotPath); os.IsNotExist(err) {
+			continue
+		}
+
+		snapshot, err := ReadSnapshot(snapshotPath)
+		if err != nil {
+			return nil, err
+		}
+
+		state = newSchemaStateFromModels(snapshot.Models)
+		start = i + 1
+		break
+	}
+
+	for _, file := range files[start:] {
+		// Migrações em Go alteram apenas dados
+		if !include(file) || file.Go != nil {
+			continue
+		}
+
+		sql, _, err := ReadMigrationSections(file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, statement := range SplitSQLStatements(dialect, sql) {
+			if err := state.apply(statement); err != nil {
+				return nil, fmt.Errorf("erro ao interpretar migração %s: %w", file.FullName, err)
+			}
+		}
+	}
+
+	return state.models(), nil
+}
+
+type schemaState struct {
+	order   []string
+	tables  map[string]*ModelInfo
+	indexes map[string]IndexInfo
+}
+
+func newSchemaState() *schemaState {
+	return &schemaState{
+		tables:  make(map[string]*ModelInfo),
+		indexes: make(map[string]IndexInfo),
+	}
+}
+
+func newSchemaStateFromModels(models []ModelInfo) *schemaState {
+	state := newSchemaState()
+
+	for _, model := range models {
+		key := strings.ToLower(model.TableName)
+		table := model
+		table.Fields = append([]FieldInfo(nil), model.Fields...)
+		table.Checks = append([]CheckInfo(nil), model.Checks...)
+		table.ForeignKeys = append([]ForeignKeyInfo(nil), model.ForeignKeys...)
+
+		// Os índices passam a ser controlados pelo estado, como nas tabelas lidas do SQL
+		table.Indexes = nil
+		for i := range table.Fields {
+			table.Fields[i].IsIndex = false
+			table.Fields[i].IsUnique = false
+		}
+
+		state.tables[key] = &table
+		state.order = append(state.order, key)
+
+		for _, index := range modelIndexes(model) {
+			state.indexes[index.Name] = index
+		}
+	}
+
+	return state
+}
+
+func (s *schemaState) apply(statement string) error {
+	statement = strings.TrimSpace(statement)
+
+	if matches := createTablePattern.FindStringSubmatch(statement); matches != nil {
+		return s.createTable(unquoteIdentifier(matches[1]), matches[2])
+	}
+
+	if matches := dropTablePattern.FindStringSubmatch(statement); matches != nil {
+		s.dropTable(unquoteIdentifier(matches[1]))
+		return nil
+	}
+
+	if matches := renameTablePattern.FindStringSubmatch(statement); matches != nil {
+		s.renameTable(unquoteIdentifier(matches[1]), unquoteIdentifier(matches[2]))
+		return nil
+	}
+
+	if matches := addPrimaryPattern.FindStringSubmatch(statement); matches != nil {
+		s.setPrimaryKey(unquoteIdentifier(matches[1]), splitTopLevel(matches[2], ','))
+		return nil
+	}
+
+	if matches := dropPrimaryPattern.FindStringSubmatch(statement); matches != nil {
+		s.setPrimaryKey(unquoteIdentifier(matches[1]), nil)
+		return nil
+	}
+
+	if matches := modifyColumnPattern.FindStringSubmatch(statement); matches != nil {
+		s.modifyColumn(unquoteIdentifier(matches[1]), parseColumnDefinition(matches[2]))
+		return nil
+	}
+
+	if matches := addCheckPattern.FindStringSubmatch(statement); matches != nil {
+		if check, ok := parseCheckDefinition(matches[2]); ok {
+			s.addCheck(unquoteIdentifier(matches[1]), check)
+		}
+		return nil
+	}
+
+	if matches := addForeignKeyPattern.FindStringSubmatch(statement); matches != nil {
+		if foreignKey, ok := parseForeignKeyDefinition(strings.TrimSuffix(strings.TrimSpace(matches[2]), ";")); ok {
+			s.addForeignKey(unquoteIdentifier(matches[1]), foreignKey)
+		}
+		return nil
+	}
+
+	if matches := dropConstraintPattern.FindStringSubmatch(statement); matches != nil {
+		s.dropConstraint(unquoteIdentifier(matches[1]), unquoteIdentifier(matches[2]))
+
+		// Remover a constraint <tabela>_pkey equivale a remover a chave primária
+		if strings.HasSuffix(strings.ToLower(unquoteIdentifier(matches[2])), "_pkey") {
+			s.setPrimaryKey(unquoteIdentifier(matches[1]), nil)
+		}
+		return nil
+	}
+
+	if matches := commentPattern.FindStringSubmatch(statement); matches != nil {
+		s.setComment(unquoteIdentifier(matches[1]), unquoteIdentifier(matches[2]), matches[3])
+		return nil
+	}
+
+	if matches := constraintPattern.FindStringSubmatch(statement); matches != nil {
+		// Remover a constraint <tabela>_pkey equivale a remover a chave primária
+		if strings.EqualFold(matches[2], "DROP") && strings.HasSuffix(strings.ToLower(unquoteIdentifier(matches[3])), "_pkey") {
+			s.setPrimaryKey(unquoteIdentifier(matches[1]), nil)
+		}
+		return nil
+	}
+
+	if matches := alterColumnPattern.FindStringSubmatch(statement); matches != nil {
+		s.alterColumn(unquoteIdentifier(matches[1]), unquoteIdentifier(matches[2]), matches[3])
+		return nil
+	}
+
+	if matches := dropColumnPattern.FindStringSubmatch(statement); matches != nil {
+		s.dropColumn(unquoteIdentifier(matches[1]), unquoteIdentifier(matches[2]))
+		return nil
+	}
+
+	if matches := addColumnPattern.FindStringSubmatch(statement); matches != nil {
+		table, ok := s.tables[strings.ToLower(unquoteIdentifier(matches[1]))]
+		if !ok {
+			return fmt.Errorf("tabela %s não encontrada para ADD COLUMN", matches[1])
+		}
+		table.Fields = append(table.Fields, parseColumnDefinition(matches[2]))
+		return nil
+	}
+
+	if index, ok := parseCreateIndex(statement); ok {
+		s.indexes[index.Name] = index
+		return nil
+	}
+
+	if matches := dropIndexPattern.FindStringSubmatch(statement); matches != nil {
+		delete(s.indexes, unquoteIdentifier(matches[1]))
+		return nil
+	}
+
+	// INSERT, UPDATE e demais comandos não alteram a estrutura
+	return nil
+}
+
+func (s *schemaState) createTable(name, body string) error {
+	key := strings.ToLower(name)
+	if _, exists := s.tables[key]; exists {
+		return nil
+	}
+
+	model := &ModelInfo{
+		Name:      name,
+		TableName: name,
+		Fields:    []FieldInfo{},
+	}
+
+	var primaryKeys []string
+	for _, definition := range splitTopLevel(body, ',') {
+		definition = strings.TrimSpace(definition)
+		upper := strings.ToUpper(definition)
+
+		if strings.HasPrefix(upper, "PRIMARY KEY") {
+			start := strings.Index(definition, "(")
+			end := strings.LastIndex(definition, ")")
+			if start >= 0 && end > start {
+				for _, column := range splitTopLevel(definition[start+1:end], ',') {
+					primaryKeys = append(primaryKeys, unquoteIdentifier(strings.TrimSpace(column)))
+				}
+			}
+			continue
+		}
+
+		if check, ok := parseCheckDefinition(definition); ok {
+			model.Checks = append(model.Checks, check)
+			continue
+		}
+
+		if foreignKey, ok := parseForeignKeyDefinition(definition); ok {
+			model.ForeignKeys = append(model.ForeignKeys, foreignKey)
+			continue
+		}
+
+		if strings.HasPrefix(upper, "CONSTRAINT") ||
+			strings.HasPrefix(upper, "UNIQUE") ||
+			strings.HasPrefix(upper, "FOREIGN KEY") ||
+			strings.HasPrefix(upper, "CHECK") {
+			continue
+		}
+
+		model.Fields = append(model.Fields, parseColumnDefinition(definition))
+	}
+
+	for _, primaryKey := range primaryKeys {
+		for i := range model.Fields {
+			if strings.EqualFold(columnName(model.Fields[i]), primaryKey) {
+				model.Fields[i].IsPrimaryKey = true
+			}
+		}
+	}
+
+	s.tables[key] = model
+	s.order = append(s.order, key)
+	return nil
+}
+
+func (s *schemaState) dropTable(name string) {
+	key := strings.ToLower(name)
+	delete(s.tables, key)
+
+	for i, tableName := range s.order {
+		if tableName == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	for indexName, index := range s.indexes {
+		if strings.EqualFold(index.Table, name) {
+			delete(s.indexes, indexName)
+		}
+	}
+}
+
+func (s *schemaState) renameTable(from, to string) {
+	fromKey := strings.ToLower(from)
+	toKey := strings.ToLower(to)
+
+	table, ok := s.tables[fromKey]
+	if !ok {
+		return
+	}
+
+	delete(s.tables, fromKey)
+	table.Name = to
+	table.TableName = to
+	s.tables[toKey] = table
+
+	for i, tableName := range s.order {
+		if tableName == fromKey {
+			s.order[i] = toKey
+		}
+	}
+
+	for indexName, index := range s.indexes {
+		if strings.EqualFold(index.Table, from) {
+			index.Table = to
+			s.indexes[indexName] = index
+		}
+	}
+}
+
+func (s *schemaState) dropColumn(tableName, column string) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	for i, field := range table.Fields {
+		if strings.EqualFold(columnName(field), column) {
+			table.Fields = append(table.Fields[:i], table.Fields[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *schemaState) addCheck(tableName string, check CheckInfo) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	s.dropConstraint(tableName, check.Name)
+	table.Checks = append(table.Checks, check)
+}
+
+func (s *schemaState) addForeignKey(tableName string, foreignKey ForeignKeyInfo) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	s.dropConstraint(tableName, foreignKey.Name)
+	table.ForeignKeys = append(table.ForeignKeys, foreignKey)
+}
+
+// dropConstraint remove a check ou a chave estrangeira com o nome informado.
+func (s *schemaState) dropConstraint(tableName, name string) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	for i, check := range table.Checks {
+		if strings.EqualFold(check.Name, name) {
+			table.Checks = append(table.Checks[:i], table.Checks[i+1:]...)
+			return
+		}
+	}
+
+	for i, foreignKey := range table.ForeignKeys {
+		if strings.EqualFold(foreignKey.Name, name) {
+			table.ForeignKeys = append(table.ForeignKeys[:i], table.ForeignKeys[i+1:]...)
+			return
+		}
+	}
+}
+
+// setComment aplica COMMENT ON COLUMN do PostgreSQL; IS NULL remove o comentário.
+func (s *schemaState) setComment(tableName, column, comment string) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	for i := range table.Fields {
+		if strings.EqualFold(columnName(table.Fields[i]), column) {
+			table.Fields[i].Comment = unquoteString(comment)
+			return
+		}
+	}
+}
+
+func (s *schemaState) setPrimaryKey(tableName string, columns []string) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	for i := range table.Fields {
+		table.Fields[i].IsPrimaryKey = false
+		for _, column := range columns {
+			if strings.EqualFold(columnName(table.Fields[i]), unquoteIdentifier(column)) {
+				table.Fields[i].IsPrimaryKey = true
+			}
+		}
+	}
+}
+
+// modifyColumn substitui a definição de uma coluna (MODIFY COLUMN do MySQL),
+// mantendo a posição e a participação na chave primária.
+func (s *schemaState) modifyColumn(tableName string, definition FieldInfo) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	for i := range table.Fields {
+		if strings.EqualFold(columnName(table.Fields[i]), definition.Name) {
+			definition.IsPrimaryKey = definition.IsPrimaryKey || table.Fields[i].IsPrimaryKey
+			table.Fields[i] = definition
+			return
+		}
+	}
+}
+
+// alterColumn aplica as formas de ALTER COLUMN geradas para o PostgreSQL:
+// TYPE, SET/DROP NOT NULL e SET/DROP DEFAULT.
+func (s *schemaState) alterColumn(tableName, column, action string) {
+	table, ok := s.tables[strings.ToLower(tableName)]
+	if !ok {
+		return
+	}
+
+	for i := range table.Fields {
+		if !strings.EqualFold(columnName(table.Fields[i]), column) {
+			continue
+		}
+
+		field := &table.Fields[i]
+		tokens := tokenizeDefinition(action)
+		if len(tokens) == 0 {
+			return
+		}
+
+		switch strings.ToUpper(strings.Join(tokens[:min(len(tokens), 3)], " ")) {
+		case "SET NOT NULL":
+			field.IsNotNull = true
+			return
+		case "DROP NOT NULL":
+			field.IsNotNull = false
+			return
+		}
+
+		switch strings.ToUpper(tokens[0]) {
+		case "TYPE":
+			var typeParts []string
+			for _, token := range tokens[1:] {
+				if strings.EqualFold(token, "USING") || strings.EqualFold(token, "COLLATE") {
+					break
+				}
+				typeParts = append(typeParts, token)
+			}
+			field.SQLType = strings.Join(typeParts, " ")
+
+			// Os tipos SERIAL do PostgreSQL equivalem a uma coluna com autoIncrement
+			if strings.HasSuffix(strings.ToUpper(field.SQLType), "SERIAL") {
+				field.AutoIncrement = true
+			}
+			field.Type = ""
+		case "SET":
+			if len(tokens) > 2 && strings.EqualFold(tokens[1], "DEFAULT") {
+				value := strings.Join(tokens[2:], " ")
+
+				// Default da sequência criada ao ativar o autoIncrement
+				if strings.HasPrefix(strings.ToLower(value), "nextval(") {
+					setAutoIncrement(field, true)
+					return
+				}
+				field.DefaultValue = value
+			}
+		case "DROP":
+			if len(tokens) > 1 && strings.EqualFold(tokens[1], "DEFAULT") {
+				if field.AutoIncrement {
+					setAutoIncrement(field, false)
+					return
+				}
+				field.DefaultValue = ""
+			}
+		}
+		return
+	}
+}
+
+// setAutoIncrement acompanha o tipo SERIAL correspondente, mantido no schema
+// como nas colunas criadas com autoIncrement.
+func setAutoIncrement(field *FieldInfo, autoIncrement bool) {
+	field.AutoIncrement = autoIncrement
+
+	if autoIncrement {
+		if serial, ok := postgresSerialTypes[strings.ToUpper(field.SQLType)]; ok {
+			field.SQLType = serial
+		}
+		return
+	}
+
+	field.SQLType = postgresBaseType(field.SQLType)
+}
+
+// models converte o estado em ModelInfo. Índices de uma coluna com o nome
+// padrão das tags index e unique são marcados nos campos para que
+// modelIndexes os reproduza; os demais vão para ModelInfo.Indexes.
+func (s *schemaState) models() []ModelInfo {
+	var models []ModelInfo
+
+	var indexNames []string
+	for name := range s.indexes {
+		indexNames = append(indexNames, name)
+	}
+	sort.Strings(indexNames)
+
+	for _, key := range s.order {
+		model := *s.tables[key]
+		model.Fields = append([]FieldInfo(nil), model.Fields...)
+		model.Checks = append([]CheckInfo(nil), model.Checks...)
+		model.ForeignKeys = append([]ForeignKeyInfo(nil), model.ForeignKeys...)
+		model.Indexes = nil
+
+		for _, name := range indexNames {
+			index := s.indexes[name]
+			if !strings.EqualFold(index.Table, model.TableName) {
+				continue
+			}
+
+			if !s.markColumnIndex(&model, index) {
+				model.Indexes = append(model.Indexes, index)
+			}
+		}
+
+		models = append(models, model)
+	}
+
+	return models
+}
+
+// markColumnIndex marca o campo quando o índice é o gerado pelas tags index
+// ou unique sem opções.
+func (s *schemaState) markColumnIndex(model *ModelInfo, index IndexInfo) bool {
+	if len(index.Columns) != 1 || index.Where != "" || indexSort(index, 0) != "" ||
+		index.Name != columnIndexName(model.TableName, index.Columns[0]) {
+		return false
+	}
+
+	for i := range model.Fields {
+		if !strings.EqualFold(columnName(model.Fields[i]), index.Columns[0]) {
+			continue
+		}
+
+		if index.Unique {
+			model.Fields[i].IsUnique = true
+		} else {
+			model.Fields[i].IsIndex = true
+		}
+		return true
+	}
+
+	return false
+}
+
+func parseColumnDefinition(definition string) FieldInfo {
+	tokens := tokenizeDefinition(definition)
+
+	field := FieldInfo{
+		Tags: make(map[string]string),
+	}
+
+	if len(tokens) == 0 {
+		return field
+	}
+
+	field.Name = unquoteIdentifier(tokens[0])
+	field.Column = field.Name
+
+	i := 1
+	var typeParts []string
+	for ; i < len(tokens); i++ {
+		if columnConstraintKeywords[strings.ToUpper(tokens[i])] {
+			break
+		}
+		typeParts = append(typeParts, tokens[i])
+	}
+	field.SQLType = strings.Join(typeParts, " ")
+
+	// Os tipos SERIAL do PostgreSQL equivalem a uma coluna com autoIncrement
+	if strings.HasSuffix(strings.ToUpper(field.SQLType), "SERIAL") {
+		field.AutoIncrement = true
+	}
+
+	for ; i < len(tokens); i++ {
+		switch strings.ToUpper(tokens[i]) {
+		case "PRIMARY":
+			field.IsPrimaryKey = true
+		case "NOT":
+			if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "NULL") {
+				field.IsNotNull = true
+				i++
+			}
+		case "UNIQUE":
+			field.IsUnique = true
+		case "AUTOINCREMENT", "AUTO_INCREMENT":
+			field.AutoIncrement = true
+		case "COMMENT":
+			if i+1 < len(tokens) {
+				field.Comment = unquoteString(tokens[i+1])
+				i++
+			}
+		case "DEFAULT":
+			if i+1 < len(tokens) {
+				field.DefaultValue = tokens[i+1]
+				i++
+			}
+		}
+	}
+
+	return field
+}
+
+// parseCheckDefinition interpreta "CONSTRAINT nome CHECK (expressão)".
+func parseCheckDefinition(definition string) (CheckInfo, bool) {
+	matches := checkPattern.FindStringSubmatch(strings.TrimSpace(definition))
+	if matches == nil {
+		return CheckInfo{}, false
+	}
+
+	return CheckInfo{
+		Name:       unquoteIdentifier(matches[1]),
+		Expression: strings.TrimSpace(matches[2]),
+	}, true
+}
+
+// parseCreateIndex interpreta um CREATE INDEX, com a ordenação das colunas e
+// a condição WHERE de índices parciais.
+func parseCreateIndex(statement string) (IndexInfo, bool) {
+	matches := createIndexPattern.FindStringSubmatch(strings.TrimSpace(statement))
+	if matches == nil {
+		return IndexInfo{}, false
+	}
+
+	index := IndexInfo{
+		Name:   unquoteIdentifier(matches[2]),
+		Table:  unquoteIdentifier(matches[3]),
+		Unique: strings.TrimSpace(matches[1]) != "",
+		Where:  strings.TrimSpace(matches[5]),
+	}
+
+	sorted := false
+	for _, column := range splitTopLevel(matches[4], ',') {
+		parts := strings.Fields(column)
+		order := ""
+		if len(parts) > 1 && (strings.EqualFold(parts[1], "ASC") || strings.EqualFold(parts[1], "DESC")) {
+			order = strings.ToUpper(parts[1])
+			sorted = true
+		}
+
+		index.Columns = append(index.Columns, unquoteIdentifier(parts[0]))
+		index.Sorts = append(index.Sorts, order)
+	}
+
+	if !sorted {
+		index.Sorts = nil
+	}
+
+	return index, true
+}
+
+// parseForeignKeyDefinition interpreta "CONSTRAINT nome FOREIGN KEY (colunas)
+// REFERENCES tabela (colunas) [ON DELETE ação] [ON UPDATE ação]".
+func parseForeignKeyDefinition(definition string) (ForeignKeyInfo, bool) {
+	matches := foreignKeyPattern.FindStringSubmatch(strings.TrimSpace(definition))
+	if matches == nil {
+		return ForeignKeyInfo{}, false
+	}
+
+	foreignKey := ForeignKeyInfo{
+		Name:     unquoteIdentifier(matches[1]),
+		RefTable: unquoteIdentifier(matches[3]),
+	}
+
+	for _, column := range splitTopLevel(matches[2], ',') {
+		foreignKey.Columns = append(foreignKey.Columns, unquoteIdentifier(column))
+	}
+
+	for _, column := range splitTopLevel(matches[4], ',') {
+		foreignKey.RefColumns = append(foreignKey.RefColumns, unquoteIdentifier(column))
+	}
+
+	for _, action := range referentialActionPattern.FindAllStringSubmatch(matches[5], -1) {
+		value := strings.ToUpper(strings.Join(strings.Fields(action[2]), " "))
+		if strings.EqualFold(action[1], "DELETE") {
+			foreignKey.OnDelete = value
+		} else {
+			foreignKey.OnUpdate = value
+		}
+	}
+
+	return foreignKey, true
+}
+
+// unquoteString remove as aspas de uma string SQL ('texto' ou NULL).
+func unquoteString(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "NULL") {
+		return ""
+	}
+
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+
+	return value
+}
+
+// tokenizeDefinition separa uma definição de coluna por espaços, mantendo
+// strings entre aspas e expressões entre parênteses como um único token.
+func tokenizeDefinition(definition string) []string {
+	var tokens []string
+	var current strings.Builder
+	depth := 0
+	var quote rune
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range definition {
+		switch {
+		case quote != 0:
+			current.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			current.WriteRune(r)
+		case r == '(':
+			depth++
+			current.WriteRune(r)
+		case r == ')':
+			depth--
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t' || r == '\n' || r == '\r') && depth == 0:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// splitTopLevel divide s pelo separador ignorando ocorrências dentro de
+// parênteses ou aspas.
+func splitTopLevel(s string, separator rune) []string {
+	var parts []string
+	var current strings.Builder
+	depth := 0
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == separator && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if strings.TrimSpace(current.String()) != "" {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+func unquoteIdentifier(identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	identifier = strings.TrimSuffix(identifier, ";")
+	identifier = strings.Trim(identifier, "\"`")
+	identifier = strings.TrimPrefix(identifier, "[")
+	identifier = strings.TrimSuffix(identifier, "]")
+	return identifier
+}
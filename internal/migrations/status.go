@@ -0,0 +1,161 @@
+package migrations
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	ChecksumOK      = "ok"
+	ChecksumChanged = "changed"
+	ChecksumMissing = "missing"
+)
+
+type MigrationStatus struct {
+	Number    int        `json:"number"`
+	Name      string     `json:"name"`
+	File      string     `json:"file,omitempty"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	Checksum  string     `json:"checksum,omitempty"`
+	Duplicate bool       `json:"duplicate"`
+	Replaces  []string   `json:"replaces,omitempty"`
+}
+
+type MigrationReport struct {
+	Migrations []MigrationStatus `json:"migrations"`
+	Gaps       []int             `json:"gaps"`
+	Duplicates []int             `json:"duplicates"`
+}
+
+// BuildMigrationReport cruza os arquivos encontrados em migrations/ com os
+// registros de gaver_migrations. Migrações registradas cujo arquivo não existe
+// mais também aparecem no relatório, com checksum "missing", exceto as
+// substituídas por um squash.
+func BuildMigrationReport(files []MigrationFile, applied []AppliedMigration) (*MigrationReport, error) {
+	report := &MigrationReport{
+		Migrations: []MigrationStatus{},
+		Gaps:       []int{},
+		Duplicates: []int{},
+	}
+
+	appliedByNumber := make(map[int]AppliedMigration)
+	for _, migration := range applied {
+		appliedByNumber[migration.Number] = migration
+	}
+
+	filesPerNumber := make(map[int]int)
+	covered := make(map[ReplacedMigration]bool)
+	coveredNumbers := make(map[int]bool)
+	for _, file := range files {
+		filesPerNumber[file.Number]++
+		for _, migration := range coveredMigrations(file) {
+			covered[migration] = true
+			coveredNumbers[migration.Number] = true
+		}
+	}
+
+	for number, count := range filesPerNumber {
+		if count > 1 {
+			report.Duplicates = append(report.Duplicates, number)
+		}
+	}
+	sort.Ints(report.Duplicates)
+
+	for _, file := range files {
+		status := MigrationStatus{
+			Number:    file.Number,
+			Name:      file.Name,
+			File:      file.FullName,
+			Duplicate: filesPerNumber[file.Number] > 1,
+		}
+
+		for _, migration := range file.Replaces {
+			status.Replaces = append(status.Replaces, migration.String())
+		}
+
+		if err := setAppliedStatus(&status, file, appliedByNumber); err != nil {
+			return nil, err
+		}
+
+		report.Migrations = append(report.Migrations, status)
+	}
+
+	for _, migration := range applied {
+		if !covered[ReplacedMigration{Number: migration.Number, Name: migration.Name}] {
+			appliedAt := migration.AppliedAt
+			report.Migrations = append(report.Migrations, MigrationStatus{
+				Number:    migration.Number,
+				Name:      migration.Name,
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Checksum:  ChecksumMissing,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Migrations, func(i, j int) bool {
+		return report.Migrations[i].Number < report.Migrations[j].Number
+	})
+
+	if len(report.Migrations) > 0 {
+		last := report.Migrations[len(report.Migrations)-1].Number
+		for number := 1; number < last; number++ {
+			if filesPerNumber[number] == 0 && appliedByNumber[number].Number == 0 && !coveredNumbers[number] {
+				report.Gaps = append(report.Gaps, number)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// setAppliedStatus preenche o status de aplicação do arquivo. Um squash está
+// aplicado quando todas as migrações que substitui estão registradas, com a
+// data da mais recente.
+func setAppliedStatus(status *MigrationStatus, file MigrationFile, appliedByNumber map[int]AppliedMigration) error {
+	var appliedAt time.Time
+	var records []AppliedMigration
+
+	for _, covered := range coveredMigrations(file) {
+		migration, ok := appliedByNumber[covered.Number]
+		if !ok || migration.Name != covered.Name {
+			return nil
+		}
+
+		if migration.AppliedAt.After(appliedAt) {
+			appliedAt = migration.AppliedAt
+		}
+		records = append(records, migration)
+	}
+
+	current, err := MigrationChecksum(file)
+	if err != nil {
+		return err
+	}
+
+	checksum := ChecksumOK
+	for _, migration := range records {
+		if current == migration.Checksum {
+			continue
+		}
+
+		matches := false
+		if len(file.Replaces) > 0 {
+			matches, err = replacedChecksumMatches(file, migration)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !matches {
+			checksum = ChecksumChanged
+		}
+	}
+
+	status.Applied = true
+	status.AppliedAt = &appliedAt
+	status.Checksum = checksum
+
+	return nil
+}
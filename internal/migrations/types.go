@@ -0,0 +1,188 @@
+package migrations
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// UnmappedField é um campo que o scanner não conseguiu mapear. Sem Reason, o
+// tipo Go não tem coluna correspondente conhecida e a migração usa o tipo
+// texto do banco como fallback.
+type UnmappedField struct {
+	Model  string
+	Field  string
+	Type   string
+	Reason string
+}
+
+func (u UnmappedField) String() string {
+	if u.Reason != "" {
+		return fmt.Sprintf("%s.%s (%s): %s", u.Model, u.Field, u.Type, u.Reason)
+	}
+	return fmt.Sprintf("%s.%s (%s)", u.Model, u.Field, u.Type)
+}
+
+// Tipos do database/sql que aceitam NULL e o tipo do valor que carregam
+var sqlNullTypes = map[string]string{
+	"NullString":  "string",
+	"NullBool":    "bool",
+	"NullByte":    "uint8",
+	"NullInt16":   "int16",
+	"NullInt32":   "int32",
+	"NullInt64":   "int64",
+	"NullFloat64": "float64",
+	"NullTime":    "time.Time",
+}
+
+var basicFieldTypes = map[string]bool{
+	"string": true, "bool": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// Tipos de pacotes externos suportados pelos dialetos
+var qualifiedFieldTypes = map[string]bool{
+	"time.Time":           true,
+	"gorm.DeletedAt":      true,
+	"uuid.UUID":           true,
+	"json.RawMessage":     true,
+	"datatypes.JSON":      true,
+	"datatypes.JSONType":  true,
+	"datatypes.JSONSlice": true,
+	"datatypes.JSONMap":   true,
+}
+
+var majorVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+func knownFieldType(fieldType string) bool {
+	return basicFieldTypes[fieldType] ||
+		qualifiedFieldTypes[fieldType] ||
+		strings.HasPrefix(fieldType, "[]") ||
+		strings.HasPrefix(fieldType, "map[")
+}
+
+// typeContext identifica onde uma expressão de tipo foi escrita, para que
+// nomes locais e imports sejam resolvidos no pacote e arquivo corretos.
+type typeContext struct {
+	pkg  *scannedPackage
+	file *ast.File
+}
+
+type typeDecl struct {
+	expr    ast.Expr
+	context typeContext
+}
+
+// resolveFieldType normaliza o tipo de um campo para o mapeamento de colunas:
+// ponteiros e sql.Null* viram o tipo do valor (e tornam a coluna anulável),
+// aliases de import são trocados pelo nome do pacote e tipos nomeados do
+// projeto (ex.: type Status string) são substituídos pelo tipo subjacente.
+func (s *modelScanner) resolveFieldType(context typeContext, expr ast.Expr) (string, bool) {
+	return s.resolveType(context, expr, 0)
+}
+
+func (s *modelScanner) resolveType(context typeContext, expr ast.Expr, depth int) (string, bool) {
+	if depth > 10 {
+		return "unknown", false
+	}
+
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		fieldType, _ := s.resolveType(context, t.X, depth+1)
+		return fieldType, true
+	case *ast.ParenExpr:
+		return s.resolveType(context, t.X, depth+1)
+	case *ast.Ident:
+		switch t.Name {
+		case "byte":
+			return "uint8", false
+		case "rune":
+			return "int32", false
+		}
+
+		if basicFieldTypes[t.Name] {
+			return t.Name, false
+		}
+
+		if decl, ok := context.pkg.types[t.Name]; ok {
+			return s.resolveType(decl.context, decl.expr, depth+1)
+		}
+
+		return t.Name, false
+	case *ast.SelectorExpr:
+		pkgIdent, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "unknown", false
+		}
+
+		// Sem o import (ex.: em builtinPackageSources) o nome local é usado
+		importPath, ok := findImport(context.file, pkgIdent.Name)
+		fieldType := pkgIdent.Name + "." + t.Sel.Name
+		if ok {
+			fieldType = packageName(importPath) + "." + t.Sel.Name
+		}
+
+		if importPath == "database/sql" {
+			if valueType, ok := sqlNullTypes[t.Sel.Name]; ok {
+				return valueType, true
+			}
+		}
+
+		if fieldType == "gorm.DeletedAt" {
+			return fieldType, true
+		}
+
+		if pkg, ok := s.importPackage(importPath); ok {
+			if decl, ok := pkg.types[t.Sel.Name]; ok {
+				return s.resolveType(decl.context, decl.expr, depth+1)
+			}
+		}
+
+		return fieldType, false
+	case *ast.IndexExpr:
+		return s.resolveGenericType(context, t.X, t.Index, depth)
+	case *ast.IndexListExpr:
+		return s.resolveGenericType(context, t.X, t.Indices[0], depth)
+	case *ast.ArrayType:
+		elemType, _ := s.resolveType(context, t.Elt, depth+1)
+		if t.Len != nil {
+			return "unknown", false
+		}
+		if elemType == "uint8" {
+			return "[]byte", false
+		}
+		return "[]" + elemType, false
+	case *ast.MapType:
+		keyType, _ := s.resolveType(context, t.Key, depth+1)
+		valueType, _ := s.resolveType(context, t.Value, depth+1)
+		return fmt.Sprintf("map[%s]%s", keyType, valueType), false
+	default:
+		return "unknown", false
+	}
+}
+
+// resolveGenericType trata tipos genéricos: sql.Null[T] é T anulável e os
+// demais (ex.: datatypes.JSONType[T]) são identificados pelo tipo base.
+func (s *modelScanner) resolveGenericType(context typeContext, base, argument ast.Expr, depth int) (string, bool) {
+	baseType, nullable := s.resolveType(context, base, depth+1)
+	if baseType == "sql.Null" {
+		valueType, _ := s.resolveType(context, argument, depth+1)
+		return valueType, true
+	}
+
+	return baseType, nullable
+}
+
+// packageName deduz o nome do pacote pelo caminho de import, ignorando o
+// sufixo de versão (ex.: github.com/jackc/pgx/v5 -> pgx).
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersionPattern.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	return name
+}
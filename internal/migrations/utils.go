@@ -12,7 +12,7 @@ type GaverModule struct {
 	ProjectVersion      string   `json:"projectVersion"`
 	ProjectModules      []string `json:"projectModules"`
 	ProjectDatabaseType string   `json:"projectDatabaseType"`
-	MigrationTag        int      `json:"migrationTag"`
+	MigrationTag        int      `json:"migrationTag"` // número da última migração gerada
 }
 
 func ReadGaverModule() (*GaverModule, error) {
@@ -45,16 +45,3 @@ func WriteGaverModule(module *GaverModule) error {
 
 	return nil
 }
-
-func UpdateMigrationTag(tag int) error {
-	module, err := ReadGaverModule()
-	if err != nil {
-		return err
-	}
-
-	module.MigrationTag = tag
-	return WriteGaverModule(module)
-}
-
-
-